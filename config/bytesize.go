@@ -0,0 +1,94 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidByteSize is returned when a byte size cannot be parsed.
+var ErrInvalidByteSize = errors.New("invalid byte size")
+
+// byteUnits maps the accepted size suffixes to their multiplier.
+var byteUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// ByteSize is a number of bytes that can be loaded from the environment with an
+// optional unit suffix, e.g. "512", "64KiB" or "1MiB".
+type ByteSize int64
+
+// Decode implements envconfig.Decoder.
+func (b *ByteSize) Decode(value string) error {
+	size, err := parseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(size)
+
+	return nil
+}
+
+// BodyLimits maps a route, written as "<METHOD> <route template>" (e.g. "POST /users"),
+// to the maximum request body size accepted on it.
+type BodyLimits map[string]int64
+
+// Decode implements envconfig.Decoder. The value is a comma separated list of
+// "<METHOD> <route>=<size>" entries, for example "POST /users=64KiB,PUT /users/:id=64KiB".
+func (l *BodyLimits) Decode(value string) error {
+	limits := BodyLimits{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, sizeValue, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("%w %q: expected <METHOD> <route>=<size>", ErrInvalidByteSize, entry)
+		}
+		size, err := parseByteSize(sizeValue)
+		if err != nil {
+			return err
+		}
+		limits[strings.Join(strings.Fields(route), " ")] = size
+	}
+	*l = limits
+
+	return nil
+}
+
+// parseByteSize parses a positive byte count with an optional unit suffix. Sizes
+// that do not fit in an int64 once the unit is applied are rejected.
+func parseByteSize(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	number, multiplier := value, int64(1)
+	for _, unit := range byteUnits {
+		if trimmed, ok := strings.CutSuffix(value, unit.suffix); ok {
+			number, multiplier = strings.TrimSpace(trimmed), unit.multiplier
+
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("%w %q: expected a positive number of bytes with an optional unit (B, KiB, MiB, ...)", ErrInvalidByteSize, value)
+	}
+
+	if size > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("%w %q: size overflows int64", ErrInvalidByteSize, value)
+	}
+
+	return size * multiplier, nil
+}
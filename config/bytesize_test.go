@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestByteSizeDecode(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    ByteSize
+		wantErr bool
+	}{
+		{value: "512", want: 512},
+		{value: "512B", want: 512},
+		{value: "64KiB", want: 64 << 10},
+		{value: " 1 MiB ", want: 1 << 20},
+		{value: "2GiB", want: 2 << 30},
+		{value: "10KB", want: 10_000},
+		{value: "1MB", want: 1_000_000},
+		{value: "", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-1KiB", wantErr: true},
+		{value: "1.5MiB", wantErr: true},
+		{value: "1TiB", wantErr: true},
+		{value: "9000000000GiB", wantErr: true},
+		{value: "9223372036854775807B", want: 9223372036854775807},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var got ByteSize
+			err := got.Decode(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidByteSize) {
+					t.Errorf("Decode() error = %v, want ErrInvalidByteSize", err)
+				}
+
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Decode() = %d, %v, want %d", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimitsDecode(t *testing.T) {
+	var limits BodyLimits
+	if err := limits.Decode("POST  /users=64KiB, PUT /users/:id=1024,"); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	want := BodyLimits{"POST /users": 64 << 10, "PUT /users/:id": 1024}
+	if len(limits) != len(want) {
+		t.Fatalf("Decode() = %v, want %v", limits, want)
+	}
+	for route, size := range want {
+		if limits[route] != size {
+			t.Errorf("limits[%q] = %d, want %d", route, limits[route], size)
+		}
+	}
+
+	for _, value := range []string{"POST /users", "POST /users=lots"} {
+		if err := limits.Decode(value); !errors.Is(err, ErrInvalidByteSize) {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidByteSize", value, err)
+		}
+	}
+}
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"time"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/ratelimit"
+)
+
 // Config holds application configuration parameters, typically loaded from
 // environment variables using a library like 'kelseyhightower/envconfig'.
 // Struct tags define the corresponding environment variable names and default values.
@@ -14,4 +20,245 @@ type Config struct {
 	// It defaults to false if the DEBUG environment variable is not set or invalid.
 	// Loaded from env: DEBUG
 	Debug bool `envconfig:"DEBUG" default:"false"`
+
+	// APIV1DeprecatedAt is when version 1 of the API, served under /v1 and the unversioned
+	// paths, was deprecated in favour of version 2. Its responses announce it in a Deprecation
+	// header. Zero, the default, leaves version 1 undeprecated. Written in RFC 3339, e.g.
+	// "2026-10-18T00:00:00Z".
+	// Loaded from env: API_V1_DEPRECATED_AT
+	APIV1DeprecatedAt time.Time `envconfig:"API_V1_DEPRECATED_AT"`
+
+	// APIUnversionedDeprecatedAt is when the unversioned /users paths, which serve version 1
+	// from before the API was versioned, were deprecated in favour of the versioned paths.
+	// Their responses announce it in a Deprecation header. Zero falls back to APIV1DeprecatedAt;
+	// if both are zero the unversioned paths are not deprecated. Written in RFC 3339.
+	// Loaded from env: API_UNVERSIONED_DEPRECATED_AT
+	APIUnversionedDeprecatedAt time.Time `envconfig:"API_UNVERSIONED_DEPRECATED_AT"`
+
+	// APIV1Sunset is when version 1 of the API will be removed, announced in a Sunset header
+	// on its responses. Zero omits the header. Written in RFC 3339.
+	// Loaded from env: API_V1_SUNSET
+	APIV1Sunset time.Time `envconfig:"API_V1_SUNSET"`
+
+	// CompressionEncodings lists the content codings responses may be compressed with, in order
+	// of preference, comma separated: "br", "zstd" and "gzip". The client's Accept-Encoding
+	// header decides which one is used. When empty, responses are not compressed.
+	// Loaded from env: COMPRESSION_ENCODINGS
+	CompressionEncodings []string `envconfig:"COMPRESSION_ENCODINGS" default:"br,zstd,gzip"`
+
+	// CompressionMinSize is the smallest response body that is compressed. Accepts a unit suffix.
+	// Loaded from env: COMPRESSION_MIN_SIZE
+	CompressionMinSize ByteSize `envconfig:"COMPRESSION_MIN_SIZE" default:"1KiB"`
+
+	// ReadHeaderTimeout bounds how long the server waits for a client to send the request headers,
+	// protecting against slow-loris style connections.
+	// Loaded from env: READ_HEADER_TIMEOUT
+	ReadHeaderTimeout time.Duration `envconfig:"READ_HEADER_TIMEOUT" default:"5s"`
+
+	// ReadTimeout bounds how long the server spends reading an entire request, including the body.
+	// Loaded from env: READ_TIMEOUT
+	ReadTimeout time.Duration `envconfig:"READ_TIMEOUT" default:"15s"`
+
+	// WriteTimeout bounds how long the server spends writing a response, measured from the end
+	// of the request headers.
+	// Loaded from env: WRITE_TIMEOUT
+	WriteTimeout time.Duration `envconfig:"WRITE_TIMEOUT" default:"30s"`
+
+	// IdleTimeout is how long an idle keep-alive connection is kept open waiting for the next request.
+	// Loaded from env: IDLE_TIMEOUT
+	IdleTimeout time.Duration `envconfig:"IDLE_TIMEOUT" default:"120s"`
+
+	// RequestTimeout is the deadline of requests to the /users route groups. Service calls still
+	// running when it expires are cancelled and the request is answered with HTTP 504 Gateway Timeout.
+	// It should stay below WriteTimeout, so the error response can still be written. Zero disables it.
+	// Loaded from env: REQUEST_TIMEOUT
+	RequestTimeout time.Duration `envconfig:"REQUEST_TIMEOUT" default:"10s"`
+
+	// RequestTimeoutGroups overrides RequestTimeout on specific route groups, as a comma separated
+	// list of "<group>=<duration>" entries, e.g. "/v1/users=5s,/users=5s". The groups are
+	// /v1/users, /v2/users and the unversioned /users. Zero disables the deadline of a group.
+	// Loaded from env: REQUEST_TIMEOUT_GROUPS
+	RequestTimeoutGroups GroupTimeouts `envconfig:"REQUEST_TIMEOUT_GROUPS" default:""`
+
+	// MaxHeaderBytes caps the size of the request line and headers. Larger requests are
+	// rejected by the server with HTTP 431 Request Header Fields Too Large.
+	// Accepts a unit suffix, e.g. "64KiB".
+	// Loaded from env: MAX_HEADER_BYTES
+	MaxHeaderBytes ByteSize `envconfig:"MAX_HEADER_BYTES" default:"64KiB"`
+
+	// MaxBodyBytes is the default maximum request body size. Larger bodies are rejected
+	// with HTTP 413 Request Entity Too Large. Accepts a unit suffix, e.g. "1MiB".
+	// Loaded from env: MAX_BODY_BYTES
+	MaxBodyBytes ByteSize `envconfig:"MAX_BODY_BYTES" default:"1MiB"`
+
+	// BodyLimitRoutes overrides MaxBodyBytes on specific routes, as a comma separated list of
+	// "<METHOD> <route template>=<size>" entries, e.g. "POST /users=64KiB". Route templates
+	// omit the API version prefix and apply to every version of the route.
+	// Loaded from env: BODY_LIMIT_ROUTES
+	BodyLimitRoutes BodyLimits `envconfig:"BODY_LIMIT_ROUTES" default:"POST /users=64KiB,PUT /users/:id=64KiB,PUT /users/me=64KiB,PATCH /users/me=64KiB"`
+
+	// ShutdownTimeout bounds how long in-flight requests may take to finish after SIGINT/SIGTERM
+	// before remaining connections are closed. Cloud Run allows 10 seconds after SIGTERM.
+	// Loaded from env: SHUTDOWN_TIMEOUT
+	ShutdownTimeout time.Duration `envconfig:"SHUTDOWN_TIMEOUT" default:"9s"`
+
+	// ShutdownDrainDelay is how long the server keeps accepting requests with a failing readiness
+	// probe after a termination signal, giving load balancers time to stop routing to it.
+	// It counts towards ShutdownTimeout.
+	// Loaded from env: SHUTDOWN_DRAIN_DELAY
+	ShutdownDrainDelay time.Duration `envconfig:"SHUTDOWN_DRAIN_DELAY" default:"0s"`
+
+	// HealthCheckTimeout bounds each readiness check (store, disk, rate limit backend, ...).
+	// Loaded from env: HEALTH_CHECK_TIMEOUT
+	HealthCheckTimeout time.Duration `envconfig:"HEALTH_CHECK_TIMEOUT" default:"2s"`
+
+	// HealthCacheTTL is how long readiness check results are reused before running again,
+	// protecting dependencies from frequent or concurrent probes.
+	// Loaded from env: HEALTH_CACHE_TTL
+	HealthCacheTTL time.Duration `envconfig:"HEALTH_CACHE_TTL" default:"5s"`
+
+	// HealthDiskPath is the directory the "disk_writable" readiness check writes a probe file to.
+	// When empty, the system temporary directory is used.
+	// Loaded from env: HEALTH_DISK_PATH
+	HealthDiskPath string `envconfig:"HEALTH_DISK_PATH" default:""`
+
+	// TunnelMetricsURL is the cloudflared Prometheus metrics endpoint scraped by the optional
+	// "tunnel" readiness check (e.g. "http://tunnel:60123/metrics"). When empty, the check is disabled.
+	// Loaded from env: TUNNEL_METRICS_URL
+	TunnelMetricsURL string `envconfig:"TUNNEL_METRICS_URL" default:""`
+
+	// TunnelMinConnections is the number of edge connections expected from a healthy cloudflared
+	// connector. Fewer connections report the tunnel as degraded.
+	// Loaded from env: TUNNEL_MIN_CONNECTIONS
+	TunnelMinConnections int `envconfig:"TUNNEL_MIN_CONNECTIONS" default:"4"`
+
+	// ServiceName identifies the service in traces (service.name) and server spans.
+	// Loaded from env: SERVICE_NAME
+	ServiceName string `envconfig:"SERVICE_NAME" default:"user-service"`
+
+	// LogFormat selects the log output: "json" (flat structured JSON), "gcp" (JSON with
+	// Cloud Logging severity names, `httpRequest` objects and trace correlation) or
+	// "console" (human readable). When empty, "console" is used in debug mode and "json" otherwise.
+	// Loaded from env: LOG_FORMAT
+	LogFormat string `envconfig:"LOG_FORMAT" default:""`
+
+	// GCPProjectID is the Google Cloud project the service runs in. It is used to build
+	// trace resource names for Cloud Logging when LogFormat is "gcp".
+	// Loaded from env: GCP_PROJECT_ID
+	GCPProjectID string `envconfig:"GCP_PROJECT_ID" default:""`
+
+	// MetricsPort is the port of a separate admin listener serving Prometheus metrics on /metrics.
+	// When empty, /metrics is served by the main router instead. A separate port keeps the
+	// metrics out of reach of clients coming through the tunnel.
+	// Loaded from env: METRICS_PORT
+	MetricsPort string `envconfig:"METRICS_PORT" default:""`
+
+	// TracingExporter selects where OpenTelemetry spans are sent: "none" (disabled), "otlp"
+	// (OTLP/HTTP, configured through the standard OTEL_EXPORTER_OTLP_* variables), "stdout" or
+	// "file" (JSON spans written to TracingFile, for local testing).
+	// Loaded from env: TRACING_EXPORTER
+	TracingExporter string `envconfig:"TRACING_EXPORTER" default:"none"`
+
+	// TracingFile is the file spans are appended to when TracingExporter is "file".
+	// Loaded from env: TRACING_FILE
+	TracingFile string `envconfig:"TRACING_FILE" default:"traces.jsonl"`
+
+	// TracingSampleRatio is the fraction of new traces that are sampled (0 to 1). Requests
+	// carrying a `traceparent` header keep the sampling decision of the caller.
+	// Loaded from env: TRACING_SAMPLE_RATIO
+	TracingSampleRatio float64 `envconfig:"TRACING_SAMPLE_RATIO" default:"1"`
+
+	// IdempotencyTTL is how long Idempotency-Key values sent to POST /users, and the responses
+	// they produced, are remembered in memory by the instance that served the first request.
+	// Retries within the TTL get the original response replayed. Zero disables idempotency keys.
+	// Loaded from env: IDEMPOTENCY_TTL
+	IdempotencyTTL time.Duration `envconfig:"IDEMPOTENCY_TTL" default:"24h"`
+
+	// CrashReportWebhookURL is a URL every panic recovered while serving a request is POSTed to
+	// as a JSON crash report, e.g. a chat or incident management webhook. When empty, panics
+	// are only logged and counted.
+	// Loaded from env: CRASH_REPORT_WEBHOOK_URL
+	CrashReportWebhookURL string `envconfig:"CRASH_REPORT_WEBHOOK_URL" default:""`
+
+	// CORSAllowedOrigins lists the browser origins allowed to call the /users routes, comma
+	// separated. Entries are exact origins ("https://admin.example.com"), wildcard subdomains
+	// ("https://*.example.com") or "*". When empty, CORS is disabled.
+	// Loaded from env: CORS_ALLOWED_ORIGINS
+	CORSAllowedOrigins []string `envconfig:"CORS_ALLOWED_ORIGINS" default:""`
+
+	// CORSAllowedMethods lists the methods allowed in cross-origin requests, comma separated.
+	// Loaded from env: CORS_ALLOWED_METHODS
+	CORSAllowedMethods []string `envconfig:"CORS_ALLOWED_METHODS" default:"GET,POST,PUT,PATCH,DELETE"`
+
+	// CORSAllowedHeaders lists the request headers allowed in cross-origin requests, comma
+	// separated, or "*" to allow any header.
+	// Loaded from env: CORS_ALLOWED_HEADERS
+	CORSAllowedHeaders []string `envconfig:"CORS_ALLOWED_HEADERS" default:"Authorization,Content-Type,Idempotency-Key,X-Request-ID"`
+
+	// CORSExposedHeaders lists the response headers browser scripts may read, comma separated.
+	// Loaded from env: CORS_EXPOSED_HEADERS
+	CORSExposedHeaders []string `envconfig:"CORS_EXPOSED_HEADERS" default:"X-Request-ID,Idempotent-Replayed,Retry-After,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Deprecation,Sunset,Link"`
+
+	// CORSAllowCredentials allows cross-origin requests carrying cookies or HTTP authentication.
+	// Loaded from env: CORS_ALLOW_CREDENTIALS
+	CORSAllowCredentials bool `envconfig:"CORS_ALLOW_CREDENTIALS" default:"false"`
+
+	// CORSMaxAge is how long browsers may cache the answer to a preflight request.
+	// Loaded from env: CORS_MAX_AGE
+	CORSMaxAge time.Duration `envconfig:"CORS_MAX_AGE" default:"10m"`
+
+	// ClientIPHeader names a trusted request header carrying the client's IP address, set by
+	// the edge in front of the service (e.g. "CF-Connecting-IP", set by Cloudflare for traffic
+	// arriving through the tunnel). The client IP keys rate limits and is logged.
+	// When empty, the IP of the connecting peer is used, which behind cloudflared is the
+	// connector's for every client. Only set this when every request is guaranteed to pass
+	// through the edge, as clients could otherwise send the header themselves.
+	// Loaded from env: CLIENT_IP_HEADER
+	ClientIPHeader string `envconfig:"CLIENT_IP_HEADER" default:""`
+
+	// AuthEmailHeader names a trusted request header carrying the authenticated caller's
+	// email address (e.g. "Cf-Access-Authenticated-User-Email" behind Cloudflare Access).
+	// When empty, no identity is resolved and the /users/me endpoints respond with 401.
+	// Only set this when every request is guaranteed to pass through the authenticating proxy.
+	// Loaded from env: AUTH_EMAIL_HEADER
+	AuthEmailHeader string `envconfig:"AUTH_EMAIL_HEADER" default:""`
+
+	// RateLimitBackend selects where rate limit buckets are stored: "memory" (per instance),
+	// "redis" (shared through RateLimitRedisAddr) or "none" to disable rate limiting.
+	// Loaded from env: RATE_LIMIT_BACKEND
+	RateLimitBackend string `envconfig:"RATE_LIMIT_BACKEND" default:"memory"`
+
+	// RateLimitRedisAddr is the host:port of the Redis-compatible server used when
+	// RateLimitBackend is "redis".
+	// Loaded from env: RATE_LIMIT_REDIS_ADDR
+	RateLimitRedisAddr string `envconfig:"RATE_LIMIT_REDIS_ADDR" default:"localhost:6379"`
+
+	// RateLimitDefault is the limit applied to routes without an entry in RateLimitRoutes,
+	// written as "<requests>/<period>[:<burst>]" (e.g. "100/1m"). Empty disables the default.
+	// Loaded from env: RATE_LIMIT_DEFAULT
+	RateLimitDefault ratelimit.Limit `envconfig:"RATE_LIMIT_DEFAULT" default:""`
+
+	// RateLimitRoutes holds per-route limits as a comma separated list of
+	// "<METHOD> <route>=<limit>" entries (e.g. "POST /users=10/1m,GET /users=100/1m").
+	// Routes omit the API version prefix and apply to every version of the route.
+	// Loaded from env: RATE_LIMIT_ROUTES
+	RateLimitRoutes ratelimit.Routes `envconfig:"RATE_LIMIT_ROUTES" default:"POST /users=10/1m"`
+
+	// RateLimitAPIKeyHeader names the request header identifying API clients for rate limiting
+	// (e.g. "Cf-Access-Client-Id" for Cloudflare Access service tokens). When empty, clients are
+	// keyed by identity or IP. Only set this when the key is validated upstream.
+	// Loaded from env: RATE_LIMIT_API_KEY_HEADER
+	RateLimitAPIKeyHeader string `envconfig:"RATE_LIMIT_API_KEY_HEADER" default:""`
+
+	// Geocoder selects how the coordinates of user addresses are resolved: "offline"
+	// (the centre of the address's country, without network access) or "none" to
+	// store addresses without coordinates.
+	// Loaded from env: GEOCODER
+	Geocoder string `envconfig:"GEOCODER" default:"offline"`
+
+	// PhoneDefaultRegion is the ISO 3166-1 alpha-2 code of the region of phone numbers
+	// written without a country calling code, e.g. "US" to read "(201) 555-0123" as
+	// +12015550123. Phone numbers are validated and stored in E.164 format.
+	// Loaded from env: PHONE_DEFAULT_REGION
+	PhoneDefaultRegion string `envconfig:"PHONE_DEFAULT_REGION" default:"US"`
 }
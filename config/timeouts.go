@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTimeout is returned when a route group timeout cannot be parsed.
+var ErrInvalidTimeout = errors.New("invalid timeout")
+
+// GroupTimeouts maps a route group path (e.g. "/v1/users") to the deadline of its requests.
+type GroupTimeouts map[string]time.Duration
+
+// Decode implements envconfig.Decoder. The value is a comma separated list of
+// "<group>=<duration>" entries, for example "/v1/users=5s,/users=5s".
+func (t *GroupTimeouts) Decode(value string) error {
+	timeouts := GroupTimeouts{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, durationValue, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("%w %q: expected <group>=<duration>", ErrInvalidTimeout, entry)
+		}
+		timeout, err := time.ParseDuration(strings.TrimSpace(durationValue))
+		if err != nil || timeout < 0 {
+			return fmt.Errorf("%w %q: expected a non-negative duration, e.g. 5s", ErrInvalidTimeout, entry)
+		}
+		timeouts[strings.TrimSpace(group)] = timeout
+	}
+	*t = timeouts
+
+	return nil
+}
+
+// For returns the timeout of group, or fallback when group has none.
+func (t GroupTimeouts) For(group string, fallback time.Duration) time.Duration {
+	if timeout, ok := t[group]; ok {
+		return timeout
+	}
+
+	return fallback
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroupTimeoutsDecode(t *testing.T) {
+	var timeouts GroupTimeouts
+	if err := timeouts.Decode(" /v1/users=5s, /users = 0s,"); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	tests := []struct {
+		group string
+		want  time.Duration
+	}{
+		{group: "/v1/users", want: 5 * time.Second},
+		{group: "/users", want: 0},
+		{group: "/v2/users", want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := timeouts.For(tt.group, 10*time.Second); got != tt.want {
+			t.Errorf("For(%q) = %v, want %v", tt.group, got, tt.want)
+		}
+	}
+
+	for _, value := range []string{"/v1/users", "/v1/users=soon", "/v1/users=-1s"} {
+		if err := timeouts.Decode(value); !errors.Is(err, ErrInvalidTimeout) {
+			t.Errorf("Decode(%q) error = %v, want ErrInvalidTimeout", value, err)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package correlation carries the identifiers used to trace a single API call
+// end to end (our request ID and Cloudflare's Ray ID) through a context.Context.
+package correlation
+
+import (
+	"context"
+)
+
+const (
+	// RequestIDHeader is the header used to accept and echo the request ID.
+	RequestIDHeader = "X-Request-ID"
+	// RayIDHeader is the header in which Cloudflare passes the Ray ID of the request
+	// through the tunnel.
+	RayIDHeader = "Cf-Ray"
+)
+
+// IDs holds the correlation identifiers of a single request.
+type IDs struct {
+	// RequestID is accepted from the X-Request-ID header or generated by the service.
+	RequestID string
+	// RayID is Cloudflare's Ray ID from the Cf-Ray header. Empty when the request
+	// did not pass through Cloudflare.
+	RayID string
+}
+
+// contextKey is an unexported type for the context key, preventing collisions
+// with keys defined in other packages.
+type contextKey struct{}
+
+// WithIDs returns a copy of ctx carrying the given correlation identifiers.
+func WithIDs(ctx context.Context, ids IDs) context.Context {
+	return context.WithValue(ctx, contextKey{}, ids)
+}
+
+// FromContext returns the correlation identifiers stored in ctx, or zero IDs if none are present.
+func FromContext(ctx context.Context) IDs {
+	ids, _ := ctx.Value(contextKey{}).(IDs)
+
+	return ids
+}
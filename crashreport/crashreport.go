@@ -0,0 +1,35 @@
+// Package crashreport delivers reports of panics recovered while serving requests
+// to an external sink, so they are noticed even when nobody watches the logs.
+package crashreport
+
+import "time"
+
+// Report describes a panic recovered while serving a request.
+type Report struct {
+	// Service is the name of the service that panicked, filled in by the sink.
+	Service string `json:"service"`
+	// Time is when the panic was recovered.
+	Time time.Time `json:"time"`
+	// Panic is the value passed to panic, formatted with fmt.Sprint.
+	Panic string `json:"panic"`
+	// Stack is the stack trace of the panicking goroutine.
+	Stack string `json:"stack"`
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// Route is the matched route template, e.g. "/users/:id".
+	Route string `json:"route,omitempty"`
+	// Path is the request path.
+	Path string `json:"path"`
+	// RequestID is the request's correlation ID.
+	RequestID string `json:"request_id,omitempty"`
+	// RayID is the Cloudflare Ray ID of the request, when it came through Cloudflare.
+	RayID string `json:"cf_ray,omitempty"`
+	// TraceID is the OpenTelemetry trace ID of the request, when it was traced.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// Sink receives crash reports. Send is called on the request goroutine and must
+// not block it; delivery happens in the background and may drop reports.
+type Sink interface {
+	Send(report Report)
+}
@@ -0,0 +1,117 @@
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// webhookQueueSize bounds the number of reports waiting for delivery. Reports
+// sent while the queue is full are dropped, so a panic storm cannot exhaust memory.
+const webhookQueueSize = 16
+
+// Webhook is a Sink posting every report as a JSON document to a URL, e.g. a
+// chat or incident management integration. Reports are delivered one at a
+// time by a background goroutine; failed deliveries are logged and not retried.
+type Webhook struct {
+	url     string
+	service string
+	client  *http.Client
+
+	mu      sync.Mutex
+	closed  bool
+	reports chan Report
+	done    chan struct{}
+}
+
+// NewWebhook creates a Webhook sink and starts its delivery goroutine.
+// Call Shutdown to deliver the queued reports and stop it.
+//
+// Parameters:
+//   - url: The URL each report is POSTed to.
+//   - service: The service name set on every report.
+//   - client: The HTTP client used for delivery; it should have a timeout.
+func NewWebhook(url, service string, client *http.Client) *Webhook {
+	w := &Webhook{
+		url:     url,
+		service: service,
+		client:  client,
+		reports: make(chan Report, webhookQueueSize),
+		done:    make(chan struct{}),
+	}
+	go w.deliver()
+
+	return w
+}
+
+// Send queues report for delivery without blocking. The report is dropped if
+// the queue is full or the sink has been shut down.
+func (w *Webhook) Send(report Report) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return
+	}
+
+	report.Service = w.service
+	select {
+	case w.reports <- report:
+	default:
+		log.Warn().Str("request_id", report.RequestID).Msg("Crash report queue full, dropping report")
+	}
+}
+
+// Shutdown stops accepting reports and waits until the queued ones are delivered
+// or ctx is done.
+func (w *Webhook) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.reports)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("deliver crash reports: %w", ctx.Err())
+	}
+}
+
+// deliver posts queued reports until the queue is closed.
+func (w *Webhook) deliver() {
+	defer close(w.done)
+
+	for report := range w.reports {
+		if err := w.post(report); err != nil {
+			log.Error().Err(err).Str("request_id", report.RequestID).Msg("Failed to deliver crash report")
+		}
+	}
+}
+
+// post sends a single report to the webhook URL.
+func (w *Webhook) post(report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("encode crash report: %w", err)
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("post crash report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("post crash report: unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
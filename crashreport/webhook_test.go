@@ -0,0 +1,81 @@
+package crashreport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var received []Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report Report
+		if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
+			t.Errorf("decode report: %v", err)
+		}
+		mu.Lock()
+		received = append(received, report)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL, "user-service", server.Client())
+	webhook.Send(Report{Panic: "boom", RequestID: "req-1"})
+	webhook.Send(Report{Panic: "bang", RequestID: "req-2"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := webhook.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	// Reports sent after shutdown are dropped without panicking on the closed queue
+	webhook.Send(Report{Panic: "late"})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 {
+		t.Fatalf("received %d reports, want 2", len(received))
+	}
+	for i, want := range []string{"boom", "bang"} {
+		if received[i].Panic != want || received[i].Service != "user-service" {
+			t.Errorf("report %d = %+v, want panic %q from user-service", i, received[i], want)
+		}
+	}
+}
+
+func TestWebhookDropsReportsWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	webhook := NewWebhook(server.URL, "user-service", server.Client())
+
+	// Send never blocks the request goroutine, even when delivery is stuck
+	done := make(chan struct{})
+	go func() {
+		for range webhookQueueSize * 2 {
+			webhook.Send(Report{Panic: "boom"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Send blocked on a full queue")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := webhook.Shutdown(ctx); err == nil {
+		t.Error("Shutdown() with undelivered reports and a done context succeeded, want an error")
+	}
+	close(release)
+}
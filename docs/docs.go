@@ -25,36 +25,134 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/users": {
+        "/livez": {
             "get": {
-                "description": "get all users currently stored",
-                "consumes": [
+                "description": "report that the process is alive",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "Process is alive",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "report whether the service and its dependencies are ready to receive traffic",
                 "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "All checks passed or are degraded",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "At least one check is down or the service is shutting down",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users": {
+            "get": {
+                "description": "get all users currently stored, or a page of them. Accepting a media type with the parameter envelope=v1 (e.g. \"application/json; envelope=v1\") wraps the users in {\"data\": [...], \"meta\": {\"total\", \"page\"}, \"links\": {...}}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
                 "tags": [
                     "users"
                 ],
                 "summary": "List all users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,first_name,preferences.sms",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "1-based page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page size, all users when omitted",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Successfully retrieved list of users",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.User"
+                                "$ref": "#/definitions/models.UserV1"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Invalid fields or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
@@ -62,10 +160,18 @@ const docTemplate = `{
             "post": {
                 "description": "add a new user to the store based on JSON payload",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
                 ],
                 "tags": [
                     "users"
@@ -80,112 +186,276 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.CreateUserRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client generated key making retries safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Successfully created user",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.UserV1"
                         }
                     },
                     "400": {
                         "description": "Validation Error or Invalid Request Format",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email address already in use, or a request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with a different body",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
             }
         },
-        "/users/{id}": {
+        "/v1/users/me": {
             "get": {
-                "description": "get user by ID string from path parameter",
+                "description": "get the user record matching the authenticated caller's email",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Get a single user by ID",
+                "summary": "Get the caller's own user",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "User ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Comma separated fields to return, e.g. id,first_name,preferences.sms",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "Successfully retrieved user",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.UserV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fields parameter",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "No user for the authenticated identity",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "update user data for the given ID based on JSON payload (PUT semantics)",
+                "description": "update profile fields and preferences of the authenticated caller (PUT semantics)",
                 "consumes": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Update an existing user",
+                "summary": "Replace the caller's own profile",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "User ID (UUID)",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Profile data to update",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateMeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation Error or Invalid Request Format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No user for the authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
                     },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update selected profile fields and preferences of the authenticated caller (PATCH semantics)",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Partially update the caller's own profile",
+                "parameters": [
                     {
-                        "description": "User data to update",
+                        "description": "Profile fields to update",
                         "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.UpdateUserRequest"
+                            "$ref": "#/definitions/handlers.PatchMeRequest"
                         }
                     }
                 ],
@@ -193,48 +463,83 @@ const docTemplate = `{
                     "200": {
                         "description": "Successfully updated user",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.UserV1"
                         }
                     },
                     "400": {
                         "description": "Validation Error or Invalid Request Format",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "No user for the authenticated identity",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "remove user from the store by ID string from path parameter",
+            }
+        },
+        "/v1/users/{id}": {
+            "get": {
+                "description": "get user by ID string from path parameter",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
                 ],
                 "tags": [
                     "users"
                 ],
-                "summary": "Delete a user by ID",
+                "summary": "Get a single user by ID",
                 "parameters": [
                     {
                         "type": "string",
@@ -243,51 +548,1200 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,first_name,preferences.sms",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Successfully deleted user (No Content)"
+                    "200": {
+                        "description": "Successfully retrieved user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fields parameter",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
                     },
                     "404": {
                         "description": "User not found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/problem.Problem"
                         }
-                    }
-                }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update user data for the given ID based on JSON payload (PUT semantics)",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update an existing user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data to update",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV1"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation Error or Invalid Request Format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email address already in use",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove user from the store by ID string from path parameter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successfully deleted user (No Content)"
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users": {
+            "get": {
+                "description": "get all users currently stored, or a page of them. Accepting a media type with the parameter envelope=v1 (e.g. \"application/json; envelope=v1\") wraps the users in {\"data\": [...], \"meta\": {\"total\", \"page\"}, \"links\": {...}}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "List all users",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,name.first,notifications.sms",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "1-based page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Page size, all users when omitted",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved list of users",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UserV2"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fields or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "add a new user to the store based on JSON payload",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User data to create",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateUserRequestV2"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Client generated key making retries safe",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Successfully created user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation Error or Invalid Request Format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email address already in use, or a request with the same Idempotency-Key is in progress",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Idempotency-Key reused with a different body",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users/me": {
+            "get": {
+                "description": "get the user record matching the authenticated caller's email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Get the caller's own user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,name.first,notifications.sms",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fields parameter",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No user for the authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update profile fields and preferences of the authenticated caller (PUT semantics)",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Replace the caller's own profile",
+                "parameters": [
+                    {
+                        "description": "Profile data to update",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateMeRequestV2"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation Error or Invalid Request Format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No user for the authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update selected profile fields and preferences of the authenticated caller (PATCH semantics)",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Partially update the caller's own profile",
+                "parameters": [
+                    {
+                        "description": "Profile fields to update",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PatchMeRequestV2"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation Error or Invalid Request Format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "No authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "No user for the authenticated identity",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v2/users/{id}": {
+            "get": {
+                "description": "get user by ID string from path parameter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Get a single user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,name.first,notifications.sms",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully retrieved user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fields parameter",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "update user data for the given ID based on JSON payload (PUT semantics)",
+                "consumes": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Update an existing user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User data to update",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateUserRequestV2"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successfully updated user",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserV2"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation Error or Invalid Request Format",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email address already in use",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request body too large",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Content-Type",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "remove user from the store by ID string from path parameter",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "application/yaml",
+                    "application/msgpack",
+                    "application/cbor"
+                ],
+                "tags": [
+                    "users-v2"
+                ],
+                "summary": "Delete a user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "User ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Successfully deleted user (No Content)"
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "No acceptable representation",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "User store unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request timed out",
+                        "schema": {
+                            "$ref": "#/definitions/problem.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AddressRequest": {
+            "type": "object",
+            "required": [
+                "city",
+                "country",
+                "lines"
+            ],
+            "properties": {
+                "city": {
+                    "description": "City is the city, town or locality. (Required)",
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "country": {
+                    "description": "Country is the ISO 3166-1 alpha-2 code of the country in upper case, e.g. \"NO\". (Required)",
+                    "type": "string"
+                },
+                "lines": {
+                    "description": "Lines holds the street address, one entry per line. (Required, 1 to 4 lines)",
+                    "type": "array",
+                    "maxItems": 4,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "postal_code": {
+                    "description": "PostalCode is the postal code, in the format of the country. Countries without\na known format accept any postal code.",
+                    "type": "string",
+                    "maxLength": 16
+                },
+                "region": {
+                    "description": "Region is the state, province or county, where the country uses one.",
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "handlers.ContactRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "phone"
+            ],
+            "properties": {
+                "email": {
+                    "description": "Email is the user's unique email address. (Required, must be valid email format)",
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code. (Required)",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateUserRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "email",
+                "first_name",
+                "last_name",
+                "phone"
+            ],
+            "properties": {
+                "address": {
+                    "description": "Address is the user's physical address as a single line of text, parsed into\nits parts with models.ParseAddress. A postal code must be valid in the country. (Required)",
+                    "type": "string"
+                },
+                "email": {
+                    "description": "Email is the user's unique email address. (Required, must be valid email format)",
+                    "type": "string"
+                },
+                "first_name": {
+                    "description": "FirstName is the user's given name. (Required)",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "last_name": {
+                    "description": "LastName is the user's family name or surname. (Required)",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "phone": {
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code. (Required)",
+                    "type": "string"
+                },
+                "preferences": {
+                    "description": "Preferences contains the user's notification settings (Email/SMS).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.CreateUserRequestV2": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the user's physical address. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.AddressRequest"
+                        }
+                    ]
+                },
+                "contact": {
+                    "description": "Contact holds the user's email address and phone number. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.ContactRequest"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name holds the user's given and family names. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.NameRequest"
+                        }
+                    ]
+                },
+                "notifications": {
+                    "description": "Notifications contains the user's notification settings (Email/SMS).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.MeContactRequest": {
+            "type": "object",
+            "required": [
+                "phone"
+            ],
+            "properties": {
+                "phone": {
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code. (Required)",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.NameRequest": {
+            "type": "object",
+            "required": [
+                "first",
+                "last"
+            ],
+            "properties": {
+                "first": {
+                    "description": "First is the user's given name. (Required)",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "last": {
+                    "description": "Last is the user's family name or surname. (Required)",
+                    "type": "string",
+                    "minLength": 1
+                }
+            }
+        },
+        "handlers.PatchMeContactRequest": {
+            "type": "object",
+            "properties": {
+                "phone": {
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code.",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.PatchMeRequest": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the user's physical address as a single line of text, parsed into\nits parts with models.ParseAddress. A postal code must be valid in the country.",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "first_name": {
+                    "description": "FirstName is the user's given name.",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "last_name": {
+                    "description": "LastName is the user's family name or surname.",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "phone": {
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code.",
+                    "type": "string"
+                },
+                "preferences": {
+                    "description": "Preferences contains the user's notification settings (Email/SMS).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.PatchPreferencesRequest"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.PatchMeRequestV2": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address replaces the user's physical address as a whole.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.AddressRequest"
+                        }
+                    ]
+                },
+                "contact": {
+                    "description": "Contact holds the user's phone number.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.PatchMeContactRequest"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name holds the user's given and family names.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.PatchNameRequest"
+                        }
+                    ]
+                },
+                "notifications": {
+                    "description": "Notifications contains the user's notification settings (Email/SMS).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.PatchPreferencesRequest"
+                        }
+                    ]
+                }
+            }
+        },
+        "handlers.PatchNameRequest": {
+            "type": "object",
+            "properties": {
+                "first": {
+                    "description": "First is the user's given name.",
+                    "type": "string",
+                    "minLength": 1
+                },
+                "last": {
+                    "description": "Last is the user's family name or surname.",
+                    "type": "string",
+                    "minLength": 1
+                }
             }
-        }
-    },
-    "definitions": {
-        "handlers.CreateUserRequest": {
+        },
+        "handlers.PatchPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Email indicates if the user wants email notifications.",
+                    "type": "boolean"
+                },
+                "sms": {
+                    "description": "SMS indicates if the user wants SMS text message notifications.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.UpdateMeRequest": {
             "type": "object",
             "required": [
                 "address",
-                "email",
                 "first_name",
                 "last_name",
                 "phone"
             ],
             "properties": {
                 "address": {
-                    "description": "Address is the user's physical address. (Required)",
-                    "type": "string"
-                },
-                "email": {
-                    "description": "Email is the user's unique email address. (Required, must be valid email format)",
+                    "description": "Address is the user's physical address as a single line of text, parsed into\nits parts with models.ParseAddress. A postal code must be valid in the country. (Required)",
                     "type": "string"
                 },
                 "first_name": {
@@ -301,7 +1755,7 @@ const docTemplate = `{
                     "minLength": 1
                 },
                 "phone": {
-                    "description": "Phone is the user's primary phone number. (Required)",
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code. (Required)",
                     "type": "string"
                 },
                 "preferences": {
@@ -314,6 +1768,43 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdateMeRequestV2": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the user's physical address. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.AddressRequest"
+                        }
+                    ]
+                },
+                "contact": {
+                    "description": "Contact holds the user's phone number. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.MeContactRequest"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name holds the user's given and family names. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.NameRequest"
+                        }
+                    ]
+                },
+                "notifications": {
+                    "description": "Notifications contains the user's notification settings (Email/SMS).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    ]
+                }
+            }
+        },
         "handlers.UpdateUserRequest": {
             "type": "object",
             "required": [
@@ -329,7 +1820,7 @@ const docTemplate = `{
                     "type": "boolean"
                 },
                 "address": {
-                    "description": "Address is the user's physical address. (Required)",
+                    "description": "Address is the user's physical address as a single line of text, parsed into\nits parts with models.ParseAddress. A postal code must be valid in the country. (Required)",
                     "type": "string"
                 },
                 "email": {
@@ -347,7 +1838,7 @@ const docTemplate = `{
                     "minLength": 1
                 },
                 "phone": {
-                    "description": "Phone is the user's primary phone number. (Required)",
+                    "description": "Phone is the user's primary phone number, in any common format; it is stored in\nE.164 format. Numbers from outside the default region need the country calling code. (Required)",
                     "type": "string"
                 },
                 "preferences": {
@@ -360,6 +1851,212 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.UpdateUserRequestV2": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "address": {
+                    "description": "Address is the user's physical address. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.AddressRequest"
+                        }
+                    ]
+                },
+                "contact": {
+                    "description": "Contact holds the user's email address and phone number. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.ContactRequest"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "Name holds the user's given and family names. (Required)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.NameRequest"
+                        }
+                    ]
+                },
+                "notifications": {
+                    "description": "Notifications contains the user's notification settings (Email/SMS).",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "Status is the state of the user's account. (Required)",
+                    "enum": [
+                        "active",
+                        "inactive"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "health.CheckResult": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "Duration is how long the check took to run.",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Error describes why the check failed. Empty when it passed.",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is UP when the check passed, DEGRADED when it returned a Degraded error\nand DOWN otherwise.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/health.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "description": "CheckedAt is when the checks ran. Reports are cached, so it may lag behind the request.",
+                    "type": "string"
+                },
+                "checks": {
+                    "description": "Checks holds the result of each check by name.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/health.CheckResult"
+                    }
+                },
+                "status": {
+                    "description": "Status is DOWN when any check is down, DEGRADED when any check is degraded\nand UP otherwise.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/health.Status"
+                        }
+                    ]
+                }
+            }
+        },
+        "health.Status": {
+            "type": "string",
+            "enum": [
+                "UP",
+                "DEGRADED",
+                "DOWN"
+            ],
+            "x-enum-varnames": [
+                "StatusUp",
+                "StatusDegraded",
+                "StatusDown"
+            ]
+        },
+        "models.Address": {
+            "type": "object",
+            "properties": {
+                "city": {
+                    "description": "City is the city, town or locality.",
+                    "type": "string"
+                },
+                "coordinates": {
+                    "description": "Coordinates is where the address is located, as resolved by a geocoder. It is\nset by the service and nil if the address could not be geocoded.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Coordinates"
+                        }
+                    ]
+                },
+                "country": {
+                    "description": "Country is the ISO 3166-1 alpha-2 code of the country, e.g. \"NO\".",
+                    "type": "string"
+                },
+                "lines": {
+                    "description": "Lines holds the street address, e.g. street, house number and apartment, one entry per line.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "postal_code": {
+                    "description": "PostalCode is the postal code, in the format of Country.",
+                    "type": "string"
+                },
+                "region": {
+                    "description": "Region is the state, province or county, where the country uses one.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Contact": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Email is the user's unique email address, used for login and communication.",
+                    "type": "string"
+                },
+                "phone": {
+                    "description": "Phone is the user's primary phone number in E.164 format, e.g. \"+12015550123\".",
+                    "type": "string"
+                },
+                "phone_formats": {
+                    "description": "PhoneFormats holds Phone formatted for display.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PhoneFormats"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.Coordinates": {
+            "type": "object",
+            "properties": {
+                "latitude": {
+                    "description": "Latitude is the north-south position, from -90 to 90.",
+                    "type": "number"
+                },
+                "longitude": {
+                    "description": "Longitude is the east-west position, from -180 to 180.",
+                    "type": "number"
+                }
+            }
+        },
+        "models.Name": {
+            "type": "object",
+            "properties": {
+                "first": {
+                    "description": "First is the user's given name.",
+                    "type": "string"
+                },
+                "last": {
+                    "description": "Last is the user's family name or surname.",
+                    "type": "string"
+                }
+            }
+        },
+        "models.PhoneFormats": {
+            "type": "object",
+            "properties": {
+                "international": {
+                    "description": "International is the number as dialled from abroad, e.g. \"+1 201-555-0123\".",
+                    "type": "string"
+                },
+                "national": {
+                    "description": "National is the number as dialled within its region, e.g. \"(201) 555-0123\".",
+                    "type": "string"
+                }
+            }
+        },
         "models.Preferences": {
             "type": "object",
             "properties": {
@@ -373,7 +2070,18 @@ const docTemplate = `{
                 }
             }
         },
-        "models.User": {
+        "models.UserStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "inactive"
+            ],
+            "x-enum-varnames": [
+                "UserStatusActive",
+                "UserStatusInactive"
+            ]
+        },
+        "models.UserV1": {
             "type": "object",
             "properties": {
                 "active": {
@@ -381,7 +2089,7 @@ const docTemplate = `{
                     "type": "boolean"
                 },
                 "address": {
-                    "description": "Address is the user's physical address (currently stored as a single string).\nConsider using a structured Address type for more detail if needed in the future.",
+                    "description": "Address is the user's physical address, formatted by Address.String.",
                     "type": "string"
                 },
                 "created_at": {
@@ -405,9 +2113,17 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "phone": {
-                    "description": "Phone is the user's primary phone number.",
+                    "description": "Phone is the user's primary phone number in E.164 format, e.g. \"+12015550123\".",
                     "type": "string"
                 },
+                "phone_formats": {
+                    "description": "PhoneFormats holds Phone formatted for display.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.PhoneFormats"
+                        }
+                    ]
+                },
                 "preferences": {
                     "description": "Preferences embeds the notification settings for the user.",
                     "allOf": [
@@ -421,6 +2137,124 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.UserV2": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the user's physical address.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Address"
+                        }
+                    ]
+                },
+                "contact": {
+                    "description": "Contact holds the user's email address and phone number.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Contact"
+                        }
+                    ]
+                },
+                "created_at": {
+                    "description": "CreatedAt records the exact date and time when the user record was created in the system.",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "ID is the unique identifier for the user, typically a UUID.",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name holds the user's given and family names.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Name"
+                        }
+                    ]
+                },
+                "notifications": {
+                    "description": "Notifications holds the channels the user wants to be notified on.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Preferences"
+                        }
+                    ]
+                },
+                "status": {
+                    "description": "Status is the state of the user's account.",
+                    "enum": [
+                        "active",
+                        "inactive"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.UserStatus"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "description": "UpdatedAt records the exact date and time when the user record was last modified.",
+                    "type": "string"
+                }
+            }
+        },
+        "problem.FieldError": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Detail explains why the value of the field was rejected.",
+                    "type": "string"
+                },
+                "field": {
+                    "description": "Field is the name of the rejected field.",
+                    "type": "string"
+                }
+            }
+        },
+        "problem.Problem": {
+            "type": "object",
+            "properties": {
+                "cf_ray": {
+                    "description": "RayID is the Cloudflare Ray ID of the request, when it came through Cloudflare.",
+                    "type": "string"
+                },
+                "code": {
+                    "description": "Code is a machine-readable error code refining Type, such as a service error code.",
+                    "type": "string"
+                },
+                "detail": {
+                    "description": "Detail is a human-readable explanation specific to this occurrence of the problem.",
+                    "type": "string"
+                },
+                "errors": {
+                    "description": "Errors lists the rejected fields of a request that failed validation.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/problem.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "Instance is a URI reference identifying this occurrence, the path of the failed request.",
+                    "type": "string"
+                },
+                "request_id": {
+                    "description": "RequestID is the request's correlation ID (see correlation.RequestIDHeader).",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is the HTTP status code of the response.",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "Title is a short, human-readable summary of the problem type.",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type is a URI reference identifying the problem type. Defaults to DefaultType.",
+                    "type": "string"
+                }
+            }
         }
     }
 }`
@@ -432,7 +2266,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "/",
 	Schemes:          []string{"https"},
 	Title:            "User Service",
-	Description:      "This is a sample server for managing users.",
+	Description:      "This is a sample server for managing users.\nVersion 1 of the users API is served under /v1 and under the original unversioned /users paths; version 2 is served under /v2.\nThe version is selected by the path only: media type parameters such as Accept: application/vnd.example+json; version=2 are not supported.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 }
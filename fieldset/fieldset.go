@@ -0,0 +1,298 @@
+// Package fieldset implements sparse fieldsets: clients select the fields of a
+// resource they need (e.g. `?fields=id,first_name,preferences.sms`) and responses
+// are projected to just those fields.
+//
+// Field paths use the `json` names of a struct's fields, with dots selecting
+// fields of nested structs. A Set is parsed and validated against the resource
+// type once, and projects values onto representation.Object values holding the
+// selected fields, so the projection is encoded by every representation format
+// with the original field names and order.
+//
+// Projection happens after a resource is loaded: the storage backends always
+// load complete records, and pushing the selection down to them is out of scope.
+package fieldset
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/representation"
+)
+
+// Set is a validated selection of fields of a struct type. The zero Set selects
+// every field, i.e. projects nothing away.
+type Set struct {
+	// projection describes how values are projected onto the selected fields.
+	projection *projection
+}
+
+// projection maps values of a source struct type onto the representation.Object
+// holding only the selected fields.
+type projection struct {
+	// source is the struct type values are projected from.
+	source reflect.Type
+	// xmlName is the XML element name of the source type, from its XMLName field.
+	xmlName string
+	// fields describes how each field of the object is filled from the source value.
+	fields []projectedField
+}
+
+// projectedField is a single field of a projection.
+type projectedField struct {
+	// source is the index of the field in the source struct.
+	source int
+	// name is the json name of the field.
+	name string
+	// xmlName is the xml name of the field.
+	xmlName string
+	// omitEmpty is set when the json tag of the field has the omitempty option.
+	omitEmpty bool
+	// nested projects the field's value further when only some of its fields are selected.
+	nested *projection
+}
+
+// selection is the tree of selected field names, keyed by json name. A nil
+// selection selects a field with all of its sub-fields.
+type selection map[string]selection
+
+// Parse parses a comma separated list of field paths and validates it against
+// the struct type t (or a pointer to it).
+//
+// An empty spec selects every field. Parsing fails if a path is empty, names a
+// field t does not have, or descends into a field that is not a struct.
+// Selecting a struct field includes all of its sub-fields, even if some of them
+// are listed as well.
+//
+// Parameters:
+//   - spec: The field paths, e.g. "id,first_name,preferences.sms".
+//   - t: The resource type, e.g. reflect.TypeFor[models.User]().
+//
+// Returns:
+//   - The parsed Set.
+//   - An error describing the first invalid path.
+func Parse(spec string, t reflect.Type) (Set, error) {
+	if strings.TrimSpace(spec) == "" {
+		return Set{}, nil
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	root := selection{}
+	for _, path := range strings.Split(spec, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			return Set{}, fmt.Errorf("empty field name in %q", spec)
+		}
+		if err := root.add("", path, t); err != nil {
+			return Set{}, err
+		}
+	}
+
+	return Set{projection: newProjection(t, root)}, nil
+}
+
+// add validates path, relative to prefix, against the struct type t and adds it to the selection.
+func (s selection) add(prefix, path string, t reflect.Type) error {
+	name, rest, nested := strings.Cut(path, ".")
+	field, ok := fieldByName(t, name)
+	if !ok {
+		return fmt.Errorf("unknown field %q", prefix+name)
+	}
+
+	sub, selected := s[name]
+	if !nested {
+		s[name] = nil
+
+		return nil
+	}
+	if !isStruct(field.Type) {
+		return fmt.Errorf("field %q has no sub-fields", prefix+name)
+	}
+	if selected && sub == nil {
+		// The whole struct is already selected.
+		return nil
+	}
+	if sub == nil {
+		sub = selection{}
+		s[name] = sub
+	}
+
+	return sub.add(prefix+name+".", rest, field.Type)
+}
+
+// newProjection builds the projection of the struct type t onto the fields in sel,
+// keeping the order of t's fields. Fields excluded from JSON are left out.
+func newProjection(t reflect.Type, sel selection) *projection {
+	p := &projection{source: t}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Name == "XMLName" && field.Type == reflect.TypeFor[xml.Name]() {
+			p.xmlName, _, _ = strings.Cut(field.Tag.Get("xml"), ",")
+
+			continue
+		}
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, tagged := jsonName(field)
+		if !tagged {
+			continue
+		}
+		sub, ok := sel[name]
+		if !ok {
+			continue
+		}
+
+		projected := projectedField{source: i, name: name, xmlName: xmlName(field), omitEmpty: omitEmpty(field)}
+		if sub != nil {
+			projected.nested = newProjection(field.Type, sub)
+		}
+		p.fields = append(p.fields, projected)
+	}
+
+	return p
+}
+
+// object returns the projection of src, a value of the source struct type. The
+// top-level object is named after the source type in XML, nested ones after the
+// field holding them.
+func (p *projection) object(src reflect.Value, top bool) representation.Object {
+	obj := representation.Object{Fields: make([]representation.Field, len(p.fields))}
+	if top {
+		obj.XMLName = p.xmlName
+	}
+	for i, field := range p.fields {
+		var value any
+		if field.nested != nil {
+			value = field.nested.object(src.Field(field.source), false)
+		} else {
+			value = src.Field(field.source).Interface()
+		}
+		obj.Fields[i] = representation.Field{Name: field.name, XMLName: field.xmlName, OmitEmpty: field.omitEmpty, Value: value}
+	}
+
+	return obj
+}
+
+// Empty reports whether s selects every field.
+func (s Set) Empty() bool {
+	return s.projection == nil
+}
+
+// Project returns v projected to the selected fields.
+//
+// v must be a value of the struct type s was parsed against, a pointer to one,
+// or a slice of either; anything else, nil pointers and values projected by an
+// empty Set are returned unchanged.
+//
+// Parameters:
+//   - v: The resource or list of resources to project.
+//
+// Returns:
+//   - The projected representation.Object, or a []representation.Object for a
+//     list, ready to be encoded by any representation format.
+func (s Set) Project(v any) any {
+	if s.Empty() || v == nil {
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+	switch {
+	case rv.Kind() == reflect.Slice:
+		elem := rv.Type().Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem != s.projection.source {
+			return v
+		}
+		out := make([]representation.Object, 0, rv.Len())
+		for i := range rv.Len() {
+			item := reflect.Indirect(rv.Index(i))
+			if !item.IsValid() {
+				continue
+			}
+			out = append(out, s.projection.object(item, true))
+		}
+
+		return out
+	case reflect.Indirect(rv).IsValid() && reflect.Indirect(rv).Type() == s.projection.source:
+		return s.projection.object(reflect.Indirect(rv), true)
+	default:
+		return v
+	}
+}
+
+// fieldByName returns the field of the struct type t with the given json name.
+func fieldByName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if fieldName, tagged := jsonName(field); tagged && fieldName == name {
+			return field, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// jsonName returns the name field is encoded under. tagged is false for fields
+// excluded from JSON with `json:"-"`.
+func jsonName(field reflect.StructField) (name string, tagged bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+// xmlName returns the element name of field in XML, or "-" if it is excluded from XML.
+func xmlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("xml"), ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	return name
+}
+
+// omitEmpty reports whether the json tag of field has the omitempty option.
+func omitEmpty(field reflect.StructField) bool {
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	return slices.Contains(strings.Split(options, ","), "omitempty")
+}
+
+// textMarshalers lists the interfaces of types that encode as scalars although
+// they are structs, such as time.Time.
+var textMarshalers = []reflect.Type{
+	reflect.TypeFor[json.Marshaler](),
+	reflect.TypeFor[encoding.TextMarshaler](),
+}
+
+// isStruct reports whether values of t encode as objects with selectable sub-fields.
+func isStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, marshaler := range textMarshalers {
+		if t.Implements(marshaler) || reflect.PointerTo(t).Implements(marshaler) {
+			return false
+		}
+	}
+
+	return true
+}
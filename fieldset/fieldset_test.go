@@ -0,0 +1,194 @@
+package fieldset
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPreferences struct {
+	Email bool `json:"email" xml:"email"`
+	SMS   bool `json:"sms" xml:"sms"`
+}
+
+type testLocation struct {
+	Lines   []string `json:"lines" xml:"lines>line"`
+	City    string   `json:"city" xml:"city"`
+	Country string   `json:"country,omitempty" xml:"country,omitempty"`
+}
+
+type testResource struct {
+	XMLName     xml.Name        `json:"-" xml:"resource"`
+	ID          string          `json:"id" xml:"id"`
+	Name        string          `json:"name" xml:"name"`
+	Location    testLocation    `json:"location" xml:"location"`
+	Preferences testPreferences `json:"preferences" xml:"preferences"`
+	CreatedAt   time.Time       `json:"created_at" xml:"created_at"`
+	secret      string
+}
+
+var testType = reflect.TypeFor[testResource]()
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr string
+	}{
+		{spec: ""},
+		{spec: " "},
+		{spec: "id,name"},
+		{spec: "id, location.city ,preferences"},
+		{spec: "location,location.city"},
+		{spec: "id,,name", wantErr: "empty field name"},
+		{spec: "email", wantErr: `unknown field "email"`},
+		{spec: "location.zip", wantErr: `unknown field "location.zip"`},
+		{spec: "secret", wantErr: `unknown field "secret"`},
+		{spec: "name.first", wantErr: `field "name" has no sub-fields`},
+		{spec: "created_at.year", wantErr: `field "created_at" has no sub-fields`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec, reflect.PointerTo(testType))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse() error = %v", err)
+				}
+
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProject(t *testing.T) {
+	resource := testResource{
+		ID:          "1",
+		Name:        "Ada",
+		Location:    testLocation{Lines: []string{"1 Main Street"}, City: "Oslo"},
+		Preferences: testPreferences{Email: true},
+		CreatedAt:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	tests := []struct {
+		spec     string
+		value    any
+		wantJSON string
+		wantXML  string
+	}{
+		{
+			spec:     "name,id",
+			value:    resource,
+			wantJSON: `{"id":"1","name":"Ada"}`,
+			wantXML:  `<resource><id>1</id><name>Ada</name></resource>`,
+		},
+		{
+			spec:     "location.lines,location.country,preferences.sms",
+			value:    &resource,
+			wantJSON: `{"location":{"lines":["1 Main Street"]},"preferences":{"sms":false}}`,
+			wantXML:  `<resource><location><lines><line>1 Main Street</line></lines></location><preferences><sms>false</sms></preferences></resource>`,
+		},
+		{
+			spec:     "location.city,location",
+			value:    resource,
+			wantJSON: `{"location":{"lines":["1 Main Street"],"city":"Oslo"}}`,
+			wantXML:  `<resource><location><lines><line>1 Main Street</line></lines><city>Oslo</city></location></resource>`,
+		},
+		{
+			spec:     "created_at",
+			value:    []*testResource{&resource, nil},
+			wantJSON: `[{"created_at":"2026-01-02T03:04:05Z"}]`,
+			wantXML:  `<resource><created_at>2026-01-02T03:04:05Z</created_at></resource>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			set, err := Parse(tt.spec, testType)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			projected := set.Project(tt.value)
+
+			data, err := json.Marshal(projected)
+			if err != nil || string(data) != tt.wantJSON {
+				t.Errorf("JSON = %s, %v, want %s", data, err, tt.wantJSON)
+			}
+			data, err = xml.Marshal(projected)
+			if err != nil || string(data) != tt.wantXML {
+				t.Errorf("XML = %s, %v, want %s", data, err, tt.wantXML)
+			}
+		})
+	}
+}
+
+func TestProjectLeavesOtherValues(t *testing.T) {
+	set, err := Parse("id", testType)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	var nilResource *testResource
+	for _, v := range []any{nil, nilResource, "id", []string{"id"}, struct{ ID string }{"1"}} {
+		if got := set.Project(v); !reflect.DeepEqual(got, v) {
+			t.Errorf("Project(%#v) = %#v, want it unchanged", v, got)
+		}
+	}
+
+	resource := testResource{ID: "1"}
+	if got := (Set{}).Project(resource); !reflect.DeepEqual(got, resource) {
+		t.Errorf("empty Set: Project() = %#v, want it unchanged", got)
+	}
+}
+
+func TestParseDoesNotRetainSelections(t *testing.T) {
+	// Every selection is a different subset of the fields; projecting values onto
+	// a type per selection would keep each type alive until the process exits.
+	names := []string{
+		"id", "name", "location", "location.lines", "location.city", "location.country",
+		"preferences", "preferences.email", "preferences.sms", "created_at",
+	}
+	resource := testResource{ID: "1", Name: "Ada"}
+	selections := 1 << len(names)
+	run := func(from, to int) {
+		for mask := from; mask < to; mask++ {
+			var paths []string
+			for i, name := range names {
+				if mask&(1<<i) != 0 {
+					paths = append(paths, name)
+				}
+			}
+			set, err := Parse(strings.Join(paths, ","), testType)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if _, err := json.Marshal(set.Project(resource)); err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+		}
+	}
+
+	// Warm up, so lazily allocated caches are not counted
+	run(1, selections/4)
+	before := heapInUse()
+	run(selections/4, selections)
+	after := heapInUse()
+
+	if growth := int64(after) - int64(before); growth > 256<<10 {
+		t.Errorf("heap grew by %d bytes over %d selections, want it to stay flat", growth, selections*3/4)
+	}
+}
+
+// heapInUse returns the bytes of live heap objects after a garbage collection.
+func heapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	return stats.HeapAlloc
+}
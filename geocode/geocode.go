@@ -0,0 +1,21 @@
+// Package geocode resolves postal addresses to coordinates. The user service
+// geocodes addresses when they are written and stores the coordinates with them.
+package geocode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+)
+
+// ErrNotFound is returned by a Geocoder that cannot locate an address.
+var ErrNotFound = errors.New("address not found")
+
+// Geocoder resolves addresses to coordinates. Implementations calling external
+// services must honour ctx, which carries the deadline of the request.
+type Geocoder interface {
+	// Geocode returns the coordinates of address, ignoring address.Coordinates.
+	// It returns ErrNotFound if the address cannot be located.
+	Geocode(ctx context.Context, address models.Address) (models.Coordinates, error)
+}
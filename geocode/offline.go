@@ -0,0 +1,57 @@
+package geocode
+
+import (
+	"context"
+	"strings"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+)
+
+// countryCentroids holds the approximate geographic centres of the countries the
+// Offline geocoder knows, keyed by ISO 3166-1 alpha-2 code.
+var countryCentroids = map[string]models.Coordinates{
+	"US": {Latitude: 39.8283, Longitude: -98.5795},
+	"GB": {Latitude: 54.7024, Longitude: -3.2766},
+	"CA": {Latitude: 56.1304, Longitude: -106.3468},
+	"AU": {Latitude: -25.2744, Longitude: 133.7751},
+	"NZ": {Latitude: -40.9006, Longitude: 174.8860},
+	"IE": {Latitude: 53.4129, Longitude: -8.2439},
+	"DE": {Latitude: 51.1657, Longitude: 10.4515},
+	"FR": {Latitude: 46.2276, Longitude: 2.2137},
+	"NL": {Latitude: 52.1326, Longitude: 5.2913},
+	"BE": {Latitude: 50.5039, Longitude: 4.4699},
+	"ES": {Latitude: 40.4637, Longitude: -3.7492},
+	"IT": {Latitude: 41.8719, Longitude: 12.5674},
+	"PT": {Latitude: 39.3999, Longitude: -8.2245},
+	"CH": {Latitude: 46.8182, Longitude: 8.2275},
+	"AT": {Latitude: 47.5162, Longitude: 14.5501},
+	"NO": {Latitude: 60.4720, Longitude: 8.4689},
+	"SE": {Latitude: 60.1282, Longitude: 18.6435},
+	"DK": {Latitude: 56.2639, Longitude: 9.5018},
+	"FI": {Latitude: 61.9241, Longitude: 25.7482},
+	"PL": {Latitude: 51.9194, Longitude: 19.1451},
+	"JP": {Latitude: 36.2048, Longitude: 138.2529},
+	"IN": {Latitude: 20.5937, Longitude: 78.9629},
+	"BR": {Latitude: -14.2350, Longitude: -51.9253},
+}
+
+// Offline is a Geocoder that needs no network access or API key. It resolves an
+// address to the centre of its country, which is enough for coarse uses such as
+// picking a region, and serves as a stand-in for a real geocoding service in
+// development and tests. Addresses in countries it does not know are not found.
+type Offline struct{}
+
+// NewOffline creates an Offline geocoder.
+func NewOffline() *Offline {
+	return &Offline{}
+}
+
+// Geocode returns the centre of address.Country, or ErrNotFound if the country is unknown.
+func (o *Offline) Geocode(_ context.Context, address models.Address) (models.Coordinates, error) {
+	centroid, ok := countryCentroids[strings.ToUpper(address.Country)]
+	if !ok {
+		return models.Coordinates{}, ErrNotFound
+	}
+
+	return centroid, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+)
+
+// HealthHandler serves the liveness and readiness probes backed by a health.Registry.
+type HealthHandler struct {
+	registry *health.Registry
+}
+
+// NewHealthHandler is a constructor function that creates and returns a new instance
+// of HealthHandler reporting on the checks held by registry.
+//
+// Parameters:
+//   - registry: The registry of named dependency checks deciding readiness.
+//
+// Returns:
+//   - A pointer to a newly created HealthHandler instance.
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
+	return &HealthHandler{
+		registry: registry,
+	}
+}
+
+// Livez handles HTTP GET requests to the /livez endpoint.
+// It reports that the process is running and able to serve HTTP. It deliberately
+// does not check any dependency, so a failing dependency never gets the instance
+// restarted; use /readyz for that.
+// @Summary		Liveness probe
+// @Description	report that the process is alive
+// @Tags			health
+// @Produce		json
+// @Success		200	{object}	map[string]string	"Process is alive"
+// @Router			/livez [get]
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Readyz handles HTTP GET requests to the /readyz endpoint.
+// It runs (or reuses the cached results of) every registered dependency check and
+// responds with the per-check detail. It responds with HTTP 200 OK when all checks
+// pass or are only degraded, and HTTP 503 Service Unavailable when any check is
+// down or the service is shutting down.
+// @Summary		Readiness probe
+// @Description	report whether the service and its dependencies are ready to receive traffic
+// @Tags			health
+// @Produce		json
+// @Success		200	{object}	health.Report	"All checks passed or are degraded"
+// @Failure		503	{object}	health.Report	"At least one check is down or the service is shutting down"
+// @Router			/readyz [get]
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	report := h.registry.Check()
+
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.JSON(status, report)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name       string
+		check      health.CheckFunc
+		draining   bool
+		wantCode   int
+		wantStatus health.Status
+	}{
+		{name: "up", check: func(context.Context) error { return nil }, wantCode: http.StatusOK, wantStatus: health.StatusUp},
+		{
+			name:       "degraded still ready",
+			check:      func(context.Context) error { return health.Degraded(errors.New("slow")) },
+			wantCode:   http.StatusOK,
+			wantStatus: health.StatusDegraded,
+		},
+		{
+			name:       "down",
+			check:      func(context.Context) error { return errors.New("unreachable") },
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+		},
+		{
+			name:       "draining",
+			check:      func(context.Context) error { return nil },
+			draining:   true,
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: health.StatusDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := health.NewReadiness()
+			registry := health.NewRegistry(readiness, 0)
+			registry.Register("dependency", time.Second, tt.check)
+			if tt.draining {
+				readiness.StartDraining()
+			}
+			handler := NewHealthHandler(registry)
+
+			engine := gin.New()
+			engine.GET("/livez", handler.Livez)
+			engine.GET("/readyz", handler.Readyz)
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			var report health.Report
+			if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+				t.Fatalf("decode report: %v", err)
+			}
+			if rec.Code != tt.wantCode || report.Status != tt.wantStatus {
+				t.Errorf("readyz = %d %s, want %d %s", rec.Code, report.Status, tt.wantCode, tt.wantStatus)
+			}
+
+			// Liveness never depends on the checks
+			rec = httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("livez = %d, want %d", rec.Code, http.StatusOK)
+			}
+		})
+	}
+}
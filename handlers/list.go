@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/router/middleware"
+)
+
+const (
+	// EnvelopeParam is the media type parameter clients set in the Accept header to
+	// receive lists wrapped in a ListResponse, e.g. "application/json; envelope=v1".
+	// Without it, lists are returned as bare arrays.
+	EnvelopeParam = "envelope"
+	// EnvelopeV1 is the current version of the ListResponse envelope.
+	EnvelopeV1 = "v1"
+
+	// MaxPerPage is the largest page size clients may request with the per_page query parameter.
+	MaxPerPage = 100
+)
+
+// ListResponse is the envelope of list responses. It carries the listed
+// resources in Data, alongside the metadata and paging links that a bare array
+// has no room for.
+type ListResponse struct {
+	// Data holds the resources on the requested page.
+	Data any `json:"data"`
+	// Meta describes the complete list and the requested page.
+	Meta ListMeta `json:"meta"`
+	// Links holds the URLs of the current, first, last and neighbouring pages.
+	Links ListLinks `json:"links"`
+}
+
+// ListMeta holds the metadata of a list response.
+type ListMeta struct {
+	// Total is the number of resources in the complete list.
+	Total int `json:"total" xml:"total"`
+	// Page describes the page returned in Data.
+	Page PageMeta `json:"page" xml:"page"`
+}
+
+// PageMeta describes a single page of a list.
+type PageMeta struct {
+	// Number is the 1-based number of the page.
+	Number int `json:"number" xml:"number"`
+	// Size is the maximum number of resources on a page.
+	Size int `json:"size" xml:"size"`
+	// Count is the number of resources on this page.
+	Count int `json:"count" xml:"count"`
+	// TotalPages is the number of pages of the list.
+	TotalPages int `json:"total_pages" xml:"total_pages"`
+}
+
+// ListLinks holds the URLs of the pages of a list, relative to the API root.
+// Prev and Next are empty on the first and last page respectively.
+type ListLinks struct {
+	// Self is the URL of the current page.
+	Self string `json:"self" xml:"self"`
+	// First is the URL of the first page.
+	First string `json:"first" xml:"first"`
+	// Prev is the URL of the previous page.
+	Prev string `json:"prev,omitempty" xml:"prev,omitempty"`
+	// Next is the URL of the next page.
+	Next string `json:"next,omitempty" xml:"next,omitempty"`
+	// Last is the URL of the last page.
+	Last string `json:"last" xml:"last"`
+}
+
+// MarshalXML encodes the envelope as a <list> element. encoding/xml names the
+// elements of a list held in an interface after their own type (e.g. <user>), so
+// Data is wrapped in a <data> element explicitly.
+func (l ListResponse) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	type items struct {
+		Items any
+	}
+
+	return e.Encode(struct {
+		XMLName xml.Name  `xml:"list"`
+		Data    items     `xml:"data"`
+		Meta    ListMeta  `xml:"meta"`
+		Links   ListLinks `xml:"links"`
+	}{Data: items{Items: l.Data}, Meta: l.Meta, Links: l.Links})
+}
+
+// page is a page of a list, as requested with the page and per_page query parameters.
+type page struct {
+	// number is the 1-based page number.
+	number int
+	// size is the page size, zero when every resource is on a single page.
+	size int
+}
+
+// pageFromQuery parses the page and per_page query parameters. page defaults to 1;
+// without per_page the whole list is a single page. Invalid values are answered
+// with HTTP 400 Bad Request and false is returned.
+func pageFromQuery(c *gin.Context) (page, bool) {
+	p := page{number: 1}
+	var fieldErrors []problem.FieldError
+
+	if value, ok := c.GetQuery("page"); ok {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			fieldErrors = append(fieldErrors, problem.FieldError{Field: "page", Detail: "page must be a positive integer"})
+		}
+		p.number = number
+	}
+	if value, ok := c.GetQuery("per_page"); ok {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > MaxPerPage {
+			fieldErrors = append(fieldErrors, problem.FieldError{
+				Field:  "per_page",
+				Detail: "per_page must be an integer between 1 and " + strconv.Itoa(MaxPerPage),
+			})
+		}
+		p.size = size
+	}
+
+	if len(fieldErrors) > 0 {
+		middleware.AbortWithProblem(c, problem.New(http.StatusBadRequest, "Invalid paging parameters").WithErrors(fieldErrors))
+
+		return page{}, false
+	}
+
+	return p, true
+}
+
+// bounds returns the slice bounds of the page in a list of total resources.
+// Pages past the end of the list are empty.
+func (p page) bounds(total int) (start, end int) {
+	if p.number > p.totalPages(total) {
+		return total, total
+	}
+	if p.size == 0 {
+		return 0, total
+	}
+
+	start = (p.number - 1) * p.size
+
+	return start, min(start+p.size, total)
+}
+
+// totalPages returns the number of pages of a list of total resources; an empty list has a single, empty page.
+func (p page) totalPages(total int) int {
+	if p.size == 0 || total == 0 {
+		return 1
+	}
+
+	return (total + p.size - 1) / p.size
+}
+
+// wantsEnvelope reports whether the client asked for lists wrapped in a ListResponse
+// through the EnvelopeParam media type parameter.
+func wantsEnvelope(c *gin.Context) bool {
+	return middleware.FormatParam(c, EnvelopeParam) == EnvelopeV1
+}
+
+// newListResponse wraps data, the resources on page p of a list of total
+// resources, in a ListResponse with links derived from the request URL.
+func newListResponse(c *gin.Context, p page, total, count int, data any) ListResponse {
+	size := p.size
+	if size == 0 {
+		size = total
+	}
+	lastPage := p.totalPages(total)
+
+	links := ListLinks{
+		Self:  pageURL(c.Request.URL, p.number),
+		First: pageURL(c.Request.URL, 1),
+		Last:  pageURL(c.Request.URL, lastPage),
+	}
+	if p.number > 1 {
+		links.Prev = pageURL(c.Request.URL, min(p.number-1, lastPage))
+	}
+	if p.number < lastPage {
+		links.Next = pageURL(c.Request.URL, p.number+1)
+	}
+
+	return ListResponse{
+		Data: data,
+		Meta: ListMeta{
+			Total: total,
+			Page:  PageMeta{Number: p.number, Size: size, Count: count, TotalPages: lastPage},
+		},
+		Links: links,
+	}
+}
+
+// pageURL returns the path and query of u with the page query parameter set to number.
+func pageURL(u *url.URL, number int) string {
+	query := u.Query()
+	query.Set("page", strconv.Itoa(number))
+
+	return (&url.URL{Path: u.Path, RawQuery: query.Encode()}).String()
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/router/middleware"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+)
+
+// listService is a UserService listing a fixed set of users; its other methods are not implemented.
+type listService struct {
+	services.UserService
+	users []models.User
+}
+
+func (s listService) GetUsers(context.Context) ([]models.User, error) {
+	return s.users, nil
+}
+
+// newListEngine returns an engine serving GET /v1/users from a store of total users with the IDs "1", "2", ...
+func newListEngine(total int) *gin.Engine {
+	users := make([]models.User, total)
+	for i := range users {
+		users[i] = models.User{ID: strconv.Itoa(i + 1), FirstName: "User " + strconv.Itoa(i+1)}
+	}
+
+	engine := gin.New()
+	engine.Use(middleware.Negotiate())
+	engine.GET("/v1/users", NewUserHandler(listService{users: users}).GetUsers)
+
+	return engine
+}
+
+func TestPageFromQuery(t *testing.T) {
+	tests := []struct {
+		query      string
+		want       page
+		wantFields []string
+	}{
+		{query: "", want: page{number: 1}},
+		{query: "page=3", want: page{number: 3}},
+		{query: "page=2&per_page=100", want: page{number: 2, size: 100}},
+		{query: "page=0", wantFields: []string{"page"}},
+		{query: "page=two", wantFields: []string{"page"}},
+		{query: "per_page=0", wantFields: []string{"per_page"}},
+		{query: "per_page=101", wantFields: []string{"per_page"}},
+		{query: "page=-1&per_page=x", wantFields: []string{"page", "per_page"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/users?"+tt.query, nil)
+
+			got, ok := pageFromQuery(c)
+			if tt.wantFields == nil {
+				if !ok || got != tt.want {
+					t.Errorf("pageFromQuery() = %+v, %t, want %+v", got, ok, tt.want)
+				}
+
+				return
+			}
+
+			if ok || rec.Code != http.StatusBadRequest {
+				t.Fatalf("pageFromQuery() ok = %t, status = %d, want false and %d", ok, rec.Code, http.StatusBadRequest)
+			}
+			var body problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			var fields []string
+			for _, fieldError := range body.Errors {
+				fields = append(fields, fieldError.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("problem fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		page           page
+		total          int
+		wantStart      int
+		wantEnd        int
+		wantTotalPages int
+	}{
+		{page: page{number: 1}, total: 5, wantStart: 0, wantEnd: 5, wantTotalPages: 1},
+		{page: page{number: 2}, total: 5, wantStart: 5, wantEnd: 5, wantTotalPages: 1},
+		{page: page{number: 1, size: 2}, total: 5, wantStart: 0, wantEnd: 2, wantTotalPages: 3},
+		{page: page{number: 3, size: 2}, total: 5, wantStart: 4, wantEnd: 5, wantTotalPages: 3},
+		{page: page{number: 4, size: 2}, total: 5, wantStart: 5, wantEnd: 5, wantTotalPages: 3},
+		{page: page{number: 2, size: 5}, total: 10, wantStart: 5, wantEnd: 10, wantTotalPages: 2},
+		{page: page{number: 1, size: 2}, total: 0, wantStart: 0, wantEnd: 0, wantTotalPages: 1},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.page.number)+"x"+strconv.Itoa(tt.page.size)+"/"+strconv.Itoa(tt.total), func(t *testing.T) {
+			start, end := tt.page.bounds(tt.total)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("bounds() = %d, %d, want %d, %d", start, end, tt.wantStart, tt.wantEnd)
+			}
+			if got := tt.page.totalPages(tt.total); got != tt.wantTotalPages {
+				t.Errorf("totalPages() = %d, want %d", got, tt.wantTotalPages)
+			}
+		})
+	}
+}
+
+func TestGetUsersEnvelope(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantIDs   []string
+		wantMeta  ListMeta
+		wantLinks ListLinks
+	}{
+		{
+			name:     "single page without per_page",
+			wantIDs:  []string{"1", "2", "3", "4", "5"},
+			wantMeta: ListMeta{Total: 5, Page: PageMeta{Number: 1, Size: 5, Count: 5, TotalPages: 1}},
+			wantLinks: ListLinks{
+				Self:  "/v1/users?page=1",
+				First: "/v1/users?page=1",
+				Last:  "/v1/users?page=1",
+			},
+		},
+		{
+			name:     "first page",
+			query:    "per_page=2",
+			wantIDs:  []string{"1", "2"},
+			wantMeta: ListMeta{Total: 5, Page: PageMeta{Number: 1, Size: 2, Count: 2, TotalPages: 3}},
+			wantLinks: ListLinks{
+				Self:  "/v1/users?page=1&per_page=2",
+				First: "/v1/users?page=1&per_page=2",
+				Next:  "/v1/users?page=2&per_page=2",
+				Last:  "/v1/users?page=3&per_page=2",
+			},
+		},
+		{
+			name:     "middle page keeps other parameters",
+			query:    "fields=id&page=2&per_page=2",
+			wantIDs:  []string{"3", "4"},
+			wantMeta: ListMeta{Total: 5, Page: PageMeta{Number: 2, Size: 2, Count: 2, TotalPages: 3}},
+			wantLinks: ListLinks{
+				Self:  "/v1/users?fields=id&page=2&per_page=2",
+				First: "/v1/users?fields=id&page=1&per_page=2",
+				Prev:  "/v1/users?fields=id&page=1&per_page=2",
+				Next:  "/v1/users?fields=id&page=3&per_page=2",
+				Last:  "/v1/users?fields=id&page=3&per_page=2",
+			},
+		},
+		{
+			name:     "last page",
+			query:    "page=3&per_page=2",
+			wantIDs:  []string{"5"},
+			wantMeta: ListMeta{Total: 5, Page: PageMeta{Number: 3, Size: 2, Count: 1, TotalPages: 3}},
+			wantLinks: ListLinks{
+				Self:  "/v1/users?page=3&per_page=2",
+				First: "/v1/users?page=1&per_page=2",
+				Prev:  "/v1/users?page=2&per_page=2",
+				Last:  "/v1/users?page=3&per_page=2",
+			},
+		},
+		{
+			name:     "past the end links back to the last page",
+			query:    "page=9&per_page=2",
+			wantIDs:  []string{},
+			wantMeta: ListMeta{Total: 5, Page: PageMeta{Number: 9, Size: 2, Count: 0, TotalPages: 3}},
+			wantLinks: ListLinks{
+				Self:  "/v1/users?page=9&per_page=2",
+				First: "/v1/users?page=1&per_page=2",
+				Prev:  "/v1/users?page=3&per_page=2",
+				Last:  "/v1/users?page=3&per_page=2",
+			},
+		},
+	}
+	engine := newListEngine(5)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, envelope := range []bool{false, true} {
+				req := httptest.NewRequest(http.MethodGet, "/v1/users?"+tt.query, nil)
+				if envelope {
+					req.Header.Set("Accept", "application/json; envelope=v1")
+				}
+				rec := httptest.NewRecorder()
+				engine.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("envelope %t: status = %d, want %d", envelope, rec.Code, http.StatusOK)
+				}
+
+				var users []models.UserV1
+				if envelope {
+					var body struct {
+						Data  []models.UserV1 `json:"data"`
+						Meta  ListMeta        `json:"meta"`
+						Links ListLinks       `json:"links"`
+					}
+					if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+						t.Fatalf("decode envelope: %v", err)
+					}
+					if body.Meta != tt.wantMeta {
+						t.Errorf("meta = %+v, want %+v", body.Meta, tt.wantMeta)
+					}
+					if body.Links != tt.wantLinks {
+						t.Errorf("links = %+v, want %+v", body.Links, tt.wantLinks)
+					}
+					users = body.Data
+				} else if err := json.Unmarshal(rec.Body.Bytes(), &users); err != nil {
+					t.Fatalf("decode list: %v", err)
+				}
+
+				ids := []string{}
+				for _, user := range users {
+					ids = append(ids, user.ID)
+				}
+				if !reflect.DeepEqual(ids, tt.wantIDs) {
+					t.Errorf("envelope %t: IDs = %v, want %v", envelope, ids, tt.wantIDs)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUsersEnvelopeFormats(t *testing.T) {
+	engine := newListEngine(3)
+	tests := []struct {
+		accept string
+		want   []string
+	}{
+		{
+			accept: "application/json; envelope=v1",
+			want:   []string{`{"data":[{"id":"2"}],"meta":{"total":3,`},
+		},
+		{
+			accept: "application/xml; envelope=v1",
+			want:   []string{"<list><data><user><id>2</id></user></data><meta><total>3</total>", "<next>/v1/users?fields=id&amp;page=3&amp;per_page=1</next>"},
+		},
+		{
+			accept: "application/yaml; envelope=v1",
+			want:   []string{"data:\n  - id: \"2\"\nmeta:\n  total: 3\n"},
+		},
+		{
+			accept: "application/xml",
+			want:   []string{"<items><user><id>2</id></user></items>"},
+		},
+		{
+			accept: "application/json; envelope=v2",
+			want:   []string{`[{"id":"2"}]`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/users?fields=id&page=2&per_page=1", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			for _, want := range tt.want {
+				if !strings.Contains(rec.Body.String(), want) {
+					t.Errorf("body = %s, want it to contain %s", rec.Body.String(), want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/nl"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	de_translations "github.com/go-playground/validator/v10/translations/de"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	nl_translations "github.com/go-playground/validator/v10/translations/nl"
+	"golang.org/x/text/language"
+)
+
+// messageLanguage is a language validation error messages are translated to.
+type messageLanguage struct {
+	// tag identifies the language in Accept-Language and Content-Language headers.
+	tag language.Tag
+	// locale holds the language's plural rules and number formats.
+	locale locales.Translator
+	// register registers the validator's own messages in the language.
+	register func(v *validator.Validate, trans ut.Translator) error
+}
+
+// messageLanguages lists the languages of validation error messages. The first is
+// the default, used when the client accepts none of them.
+var messageLanguages = []messageLanguage{
+	{tag: language.English, locale: en.New(), register: en_translations.RegisterDefaultTranslations},
+	{tag: language.German, locale: de.New(), register: de_translations.RegisterDefaultTranslations},
+	{tag: language.French, locale: fr.New(), register: fr_translations.RegisterDefaultTranslations},
+	{tag: language.Spanish, locale: es.New(), register: es_translations.RegisterDefaultTranslations},
+	{tag: language.Dutch, locale: nl.New(), register: nl_translations.RegisterDefaultTranslations},
+}
+
+// customMessages holds the messages of the validations the validator has no
+// translations for, keyed by tag and language. "{0}" is replaced by the field name.
+var customMessages = map[string]map[language.Tag]string{
+	"phone": {
+		language.English: "{0} must be a valid phone number, including the country calling code for numbers from other regions, e.g. +47 22 33 44 55",
+		language.German:  "{0} muss eine gültige Telefonnummer sein, bei Nummern aus anderen Regionen mit Ländervorwahl, z. B. +47 22 33 44 55",
+		language.French:  "{0} doit être un numéro de téléphone valide, avec l'indicatif du pays pour les numéros d'autres régions, p. ex. +47 22 33 44 55",
+		language.Spanish: "{0} debe ser un número de teléfono válido, con el prefijo del país para los números de otras regiones, p. ej. +47 22 33 44 55",
+		language.Dutch:   "{0} moet een geldig telefoonnummer zijn, met de landcode voor nummers uit andere regio's, bijv. +47 22 33 44 55",
+	},
+	"postcode_iso3166_alpha2_field": {
+		language.English: "{0} is not a valid postal code in the given country",
+		language.German:  "{0} ist keine gültige Postleitzahl im angegebenen Land",
+		language.French:  "{0} n'est pas un code postal valide dans le pays indiqué",
+		language.Spanish: "{0} no es un código postal válido en el país indicado",
+		language.Dutch:   "{0} is geen geldige postcode in het opgegeven land",
+	},
+	"postal_address": {
+		language.English: "{0} has a postal code that is not valid in its country",
+		language.German:  "{0} enthält eine Postleitzahl, die in ihrem Land nicht gültig ist",
+		language.French:  "{0} contient un code postal qui n'est pas valide dans son pays",
+		language.Spanish: "{0} contiene un código postal que no es válido en su país",
+		language.Dutch:   "{0} bevat een postcode die in het land niet geldig is",
+	},
+	"iso3166_1_alpha2": {
+		language.English: "{0} must be an ISO 3166-1 alpha-2 country code, e.g. NO",
+		language.German:  "{0} muss ein Ländercode nach ISO 3166-1 alpha-2 sein, z. B. NO",
+		language.French:  "{0} doit être un code pays ISO 3166-1 alpha-2, p. ex. NO",
+		language.Spanish: "{0} debe ser un código de país ISO 3166-1 alpha-2, p. ej. NO",
+		language.Dutch:   "{0} moet een landcode volgens ISO 3166-1 alpha-2 zijn, bijv. NO",
+	},
+}
+
+// languageMatcher matches Accept-Language headers against messageLanguages.
+var languageMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(messageLanguages))
+	for i, lang := range messageLanguages {
+		tags[i] = lang.tag
+	}
+
+	return language.NewMatcher(tags)
+}()
+
+// translators holds a translator of validation error messages for each of the
+// messageLanguages, in the same order.
+var translators = newTranslators(validate)
+
+// newTranslators registers the messages of the validations used by the request
+// structs in each of the messageLanguages with v, and returns their translators
+// in the same order. It panics if a message cannot be registered.
+func newTranslators(v *validator.Validate) []ut.Translator {
+	fallback := messageLanguages[0].locale
+	supported := make([]locales.Translator, len(messageLanguages))
+	for i, lang := range messageLanguages {
+		supported[i] = lang.locale
+	}
+	uni := ut.New(fallback, supported...)
+
+	result := make([]ut.Translator, len(messageLanguages))
+	for i, lang := range messageLanguages {
+		trans, _ := uni.GetTranslator(lang.locale.Locale())
+		if err := lang.register(v, trans); err != nil {
+			panic(err)
+		}
+		for tag, messages := range customMessages {
+			if err := v.RegisterTranslation(tag, trans, registerMessage(tag, messages[lang.tag]), translateMessage); err != nil {
+				panic(err)
+			}
+		}
+		result[i] = trans
+	}
+
+	return result
+}
+
+// registerMessage returns a validator.RegisterTranslationsFunc adding message as
+// the translation of tag.
+func registerMessage(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+// translateMessage is a validator.TranslationFunc formatting the message of the
+// failed validation with the name of the field.
+func translateMessage(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+
+	return message
+}
+
+// messageTranslator returns the translator of validation error messages in the
+// language the client prefers according to the request's Accept-Language header,
+// or in English if it accepts none of the messageLanguages. It sets the
+// Content-Language header of the response to the selected language.
+func messageTranslator(c *gin.Context) ut.Translator {
+	_, i := language.MatchStrings(languageMatcher, c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", messageLanguages[i].tag.String())
+	c.Writer.Header().Add("Vary", "Accept-Language")
+
+	return translators[i]
+}
+
+// jsonFieldName is the validator.TagNameFunc naming fields in validation errors
+// after their JSON keys, as clients send them. Fields without a JSON name keep
+// their Go name.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
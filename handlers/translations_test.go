@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+)
+
+func TestMessageTranslator(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{acceptLanguage: "", want: "en"},
+		{acceptLanguage: "de", want: "de"},
+		{acceptLanguage: "de-CH", want: "de"},
+		{acceptLanguage: "fr-CA, en;q=0.5", want: "fr"},
+		{acceptLanguage: "ja, nl;q=0.8, de;q=0.5", want: "nl"},
+		{acceptLanguage: "es-419", want: "es"},
+		{acceptLanguage: "ja", want: "en"},
+		{acceptLanguage: "not a language tag", want: "en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/users", nil)
+			c.Request.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			trans := messageTranslator(c)
+			if got := rec.Header().Get("Content-Language"); got != tt.want {
+				t.Errorf("Content-Language = %q, want %q", got, tt.want)
+			}
+			if got := trans.Locale(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("translator locale = %q, want %q", got, tt.want)
+			}
+			if got := rec.Header().Values("Vary"); !reflect.DeepEqual(got, []string{"Accept-Language"}) {
+				t.Errorf("Vary = %v, want [Accept-Language]", got)
+			}
+		})
+	}
+}
+
+func TestCustomMessagesCoverEveryLanguage(t *testing.T) {
+	for tag, messages := range customMessages {
+		for _, lang := range messageLanguages {
+			if message := messages[lang.tag]; !strings.Contains(message, "{0}") {
+				t.Errorf("message of %s in %s = %q, want one naming the field with {0}", tag, lang.tag, message)
+			}
+		}
+	}
+}
+
+// invalidRequests fail every validation tag used by the request types.
+var invalidRequests = []any{
+	&CreateUserRequestV2{},
+	&CreateUserRequestV2{
+		Name:    NameRequest{First: "Ada", Last: "Lovelace"},
+		Contact: ContactRequest{Email: "not an email", Phone: "12"},
+		Address: AddressRequest{
+			Lines:      []string{"1", "2", "3", "4", "5"},
+			City:       strings.Repeat("x", 101),
+			PostalCode: "01540",
+			Country:    "no",
+		},
+	},
+	&UpdateUserRequestV2{Status: "deleted"},
+	&PatchMeRequest{FirstName: ptr(""), Address: ptr("1 Main St, Springfield, IL 6270, US")},
+}
+
+func TestFormatValidationErrorsTranslates(t *testing.T) {
+	ctx := context.WithValue(context.Background(), userServiceKey{}, services.NewUserService(nil, "NO"))
+
+	failed := make(map[string]bool)
+	for _, req := range invalidRequests {
+		err := validate.StructCtx(ctx, req)
+		var validationErrors validator.ValidationErrors
+		if !errors.As(err, &validationErrors) {
+			t.Fatalf("StructCtx(%T) error = %v, want validation errors", req, err)
+		}
+		for _, fieldError := range validationErrors {
+			failed[fieldError.Tag()] = true
+		}
+
+		for i, lang := range messageLanguages {
+			fieldErrors := formatValidationErrors(err, translators[i])
+			if len(fieldErrors) != len(validationErrors) {
+				t.Fatalf("%s: %d field errors, want %d", lang.tag, len(fieldErrors), len(validationErrors))
+			}
+			for j, fieldError := range fieldErrors {
+				tag := validationErrors[j].Tag()
+				if strings.HasPrefix(fieldError.Detail, "Invalid value for") || strings.Contains(fieldError.Detail, "Error:Field validation") {
+					t.Errorf("%s: %T %s (%s) has no message: %q", lang.tag, req, fieldError.Field, tag, fieldError.Detail)
+				}
+				if !strings.Contains(fieldError.Detail, validationErrors[j].Field()) {
+					t.Errorf("%s: %s (%s) message %q does not name the field", lang.tag, fieldError.Field, tag, fieldError.Detail)
+				}
+			}
+		}
+	}
+
+	for _, tag := range validationTags() {
+		if !failed[tag] {
+			t.Errorf("no request in invalidRequests fails the %s validation", tag)
+		}
+	}
+}
+
+func TestFormatValidationErrors(t *testing.T) {
+	ctx := context.WithValue(context.Background(), userServiceKey{}, services.NewUserService(nil, "NO"))
+	err := validate.StructCtx(ctx, &CreateUserRequestV2{
+		Name:    NameRequest{First: "Ada"},
+		Contact: ContactRequest{Email: "ada@example.com", Phone: "22 33 44 55"},
+		Address: AddressRequest{Lines: []string{"Karl Johans gate 1", ""}, City: "Oslo", Country: "NO"},
+	})
+
+	tests := []struct {
+		lang int
+		want []problem.FieldError
+	}{
+		{
+			lang: 0,
+			want: []problem.FieldError{
+				{Field: "name.last", Detail: "last is a required field"},
+				{Field: "address.lines[1]", Detail: "lines[1] is a required field"},
+			},
+		},
+		{
+			lang: 1,
+			want: []problem.FieldError{
+				{Field: "name.last", Detail: "last ist ein Pflichtfeld"},
+				{Field: "address.lines[1]", Detail: "lines[1] ist ein Pflichtfeld"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(messageLanguages[tt.lang].tag.String(), func(t *testing.T) {
+			if got := formatValidationErrors(err, translators[tt.lang]); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("formatValidationErrors() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	got := formatValidationErrors(context.Canceled, translators[0])
+	if want := []problem.FieldError{{Field: "body", Detail: "Invalid request data structure"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("formatValidationErrors() of another error = %+v, want %+v", got, want)
+	}
+}
+
+// validationTags returns the validation tags used by the request types of every
+// API version, without the tags that control validation (omitempty, dive).
+func validationTags() []string {
+	seen := make(map[reflect.Type]bool)
+	tags := make(map[string]bool)
+	var walk func(t reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := range t.NumField() {
+			field := t.Field(i)
+			for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+				name, _, _ := strings.Cut(rule, "=")
+				if name != "" && name != "omitempty" && name != "dive" {
+					tags[name] = true
+				}
+			}
+			walk(field.Type)
+		}
+	}
+	for _, version := range []userVersion{userV1, userV2} {
+		for _, newRequest := range []func() userInput{
+			version.newCreateRequest, version.newUpdateRequest, version.newUpdateMeRequest, version.newPatchMeRequest,
+		} {
+			walk(reflect.TypeOf(newRequest()))
+		}
+	}
+
+	result := make([]string, 0, len(tags))
+	for tag := range tags {
+		result = append(result, tag)
+	}
+
+	return result
+}
@@ -1,11 +1,23 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
 
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 
+	"github.com/thoughtgears/cloudflare-tunnels-poc/fieldset"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/representation"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/router/middleware"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
 )
 
@@ -14,76 +26,314 @@ import (
 // Methods associated with this struct handle incoming API requests for the /users endpoints.
 type UserHandler struct {
 	service services.UserService
+	version userVersion
 }
 
 // NewUserHandler is a constructor function that creates and returns a new instance
 // of UserHandler. It requires a UserService dependency to be injected, which will
 // be used by the handler methods to interact with the underlying user data store
-// and business logic.
+// and business logic. The handler serves version 1 of the API, representing users
+// as models.UserV1; see NewUserHandlerV2 for version 2.
 //
 // Parameters:
-//   - service: An instance implementing the services.UserService interface.
+//   - service: An instance implementing the services.UserService interface. Phone
+//     numbers in requests are validated with its NormalizePhone method.
 //
 // Returns:
 //   - A pointer to a newly created UserHandler instance.
 func NewUserHandler(service services.UserService) *UserHandler {
 	return &UserHandler{
 		service: service,
+		version: userV1,
 	}
 }
 
+// bindBody decodes the request body into obj using the representation.Format
+// selected by the request's Content-Type header (JSON if absent). On failure it
+// writes the error response and returns false: HTTP 415 Unsupported Media Type for
+// an unsupported Content-Type, HTTP 413 Request Entity Too Large if the body exceeded
+// the limit enforced by middleware.BodyLimit, HTTP 400 Bad Request otherwise.
+func bindBody(c *gin.Context, obj any) bool {
+	format, ok := representation.ForContentType(c.GetHeader("Content-Type"))
+	if !ok {
+		c.Header("Accept", strings.Join(representation.MediaTypes(), ", "))
+		middleware.AbortWithError(c, http.StatusUnsupportedMediaType,
+			"Unsupported Content-Type, supported media types are "+strings.Join(representation.MediaTypes(), ", "))
+
+		return false
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			middleware.AbortWithError(c, http.StatusRequestEntityTooLarge, middleware.BodyTooLargeMessage(tooLarge.Limit))
+
+			return false
+		}
+		middleware.AbortWithError(c, http.StatusBadRequest, "Invalid request format: "+err.Error())
+
+		return false
+	}
+
+	if err := format.Unmarshal(data, obj); err != nil {
+		middleware.AbortWithError(c, http.StatusBadRequest, "Invalid request format: "+err.Error())
+
+		return false
+	}
+
+	return true
+}
+
+// respond writes v with status in the representation.Format negotiated by
+// middleware.Negotiate. If v cannot be encoded, the error is logged and the
+// request is answered with HTTP 500 Internal Server Error instead.
+func respond(c *gin.Context, status int, v any) {
+	format := middleware.FormatFrom(c)
+	data, err := format.Marshal(v)
+	if err != nil {
+		middleware.LoggerFrom(c).Error().Err(err).Str("media_type", format.MediaType).Msg("Failed to encode response")
+		middleware.AbortWithError(c, http.StatusInternalServerError, "Failed to encode response")
+
+		return
+	}
+
+	c.Data(status, format.ContentType, data)
+}
+
+// userFields parses the sparse fieldset requested in the `fields` query parameter
+// (e.g. "id,first_name,preferences.sms") against the user resource of the handler's
+// API version. An absent or empty parameter selects every field. On an invalid
+// selection it writes an HTTP 400 Bad Request problem and returns false.
+func (h *UserHandler) userFields(c *gin.Context) (fieldset.Set, bool) {
+	fields, err := fieldset.Parse(c.Query("fields"), h.version.resource)
+	if err != nil {
+		middleware.AbortWithProblem(c, problem.New(http.StatusBadRequest, "Invalid fields parameter").
+			WithErrors([]problem.FieldError{{Field: "fields", Detail: err.Error()}}))
+
+		return fieldset.Set{}, false
+	}
+
+	return fields, true
+}
+
+// serviceErrorStatus maps the codes of services.Error values to HTTP status codes.
+var serviceErrorStatus = map[services.Code]int{
+	services.CodeNotFound:    http.StatusNotFound,
+	services.CodeConflict:    http.StatusConflict,
+	services.CodeValidation:  http.StatusBadRequest,
+	services.CodeUnavailable: http.StatusServiceUnavailable,
+}
+
+// abortWithServiceError is the central mapper from UserService errors to HTTP responses.
+//
+// Errors caused by the request deadline (see middleware.Timeout) are answered with
+// HTTP 504 Gateway Timeout. A services.Error is answered with the status mapped from
+// its Code in serviceErrorStatus and a problem carrying its message and code; an
+// unavailable store also gets a Retry-After header. Anything else is unexpected: it
+// is logged and answered with HTTP 500 Internal Server Error and message.
+//
+// Parameters:
+//   - c: The request's gin context.
+//   - err: The error returned by the UserService.
+//   - message: The problem detail used for unexpected errors, e.g. "Failed to update user".
+func abortWithServiceError(c *gin.Context, err error, message string) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		middleware.LoggerFrom(c).Warn().Err(err).Msg(message)
+		middleware.AbortWithTimeout(c)
+
+		return
+	}
+
+	var serviceErr *services.Error
+	status, ok := 0, false
+	if errors.As(err, &serviceErr) {
+		status, ok = serviceErrorStatus[serviceErr.Code]
+	}
+	if !ok {
+		middleware.LoggerFrom(c).Error().Err(err).Msg(message)
+		middleware.AbortWithError(c, http.StatusInternalServerError, message)
+
+		return
+	}
+
+	if status >= http.StatusInternalServerError {
+		middleware.LoggerFrom(c).Warn().Err(err).Msg(message)
+	}
+	if status == http.StatusServiceUnavailable {
+		c.Header("Retry-After", "1")
+	}
+	p := problem.New(status, serviceErr.Message)
+	p.Code = string(serviceErr.Code)
+	middleware.AbortWithProblem(c, p)
+}
+
 // validate holds a package-level instance of the validator engine.
 // It is initialized once and reused by handler functions within this package
 // to validate incoming request data transfer objects (DTOs) or request structs
 // based on the 'validate' struct tags.
-var validate = validator.New()
+var validate = newValidator()
+
+// newValidator creates the validator engine, naming fields after their JSON keys
+// (see jsonFieldName), with the custom validations used by the request structs:
+//   - postcode_iso3166_alpha2_field: replaces the built-in validation of the same
+//     name so that postal codes of countries the validator has no format for (see
+//     countriesWithoutPostcodeFormat) are valid instead of always rejected.
+//   - postal_address: the value is an address written as text whose postal code, if
+//     any, is valid in its country (see models.ParseAddress), checked like the
+//     postcode_iso3166_alpha2_field validation of structured addresses.
+//   - phone: the value is a phone number the services.UserService stored in the
+//     context passed to validate.StructCtx can normalise (see
+//     UserHandler.validateRequest), so numbers without a country calling code are
+//     read as numbers of the service's default region.
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonFieldName)
+	if err := v.RegisterValidation("postcode_iso3166_alpha2_field", func(fl validator.FieldLevel) bool {
+		return isPostcodeOfCountryField(v, fl)
+	}); err != nil {
+		panic(err)
+	}
+	if err := v.RegisterValidation("postal_address", func(fl validator.FieldLevel) bool {
+		return isPostalAddress(v, fl)
+	}); err != nil {
+		panic(err)
+	}
+	if err := v.RegisterValidationCtx("phone", isPhone); err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// countriesWithoutPostcodeFormat holds the ISO 3166-1 alpha-2 codes the validator
+// (v10.26.0) has no postal code format for. Its postcode_iso3166_alpha2 validations
+// reject every postal code of these countries, whether the country has no postal
+// codes at all (e.g. HK, AE) or uses a format the validator does not know (e.g. IE).
+var countriesWithoutPostcodeFormat = map[string]struct{}{
+	"AE": {}, "AF": {}, "AG": {}, "AI": {}, "AL": {}, "AO": {}, "AQ": {}, "AW": {}, "BF": {}, "BI": {},
+	"BJ": {}, "BL": {}, "BO": {}, "BQ": {}, "BS": {}, "BT": {}, "BV": {}, "BW": {}, "BZ": {}, "CD": {},
+	"CF": {}, "CG": {}, "CI": {}, "CM": {}, "CO": {}, "CU": {}, "CW": {}, "DJ": {}, "DM": {}, "EH": {},
+	"ER": {}, "FJ": {}, "GA": {}, "GD": {}, "GH": {}, "GI": {}, "GM": {}, "GQ": {}, "GY": {}, "HK": {},
+	"IE": {}, "IR": {}, "JM": {}, "KI": {}, "KM": {}, "KN": {}, "KP": {}, "KY": {}, "LC": {}, "LY": {},
+	"MF": {}, "ML": {}, "MM": {}, "MO": {}, "MR": {}, "MS": {}, "MW": {}, "MZ": {}, "NA": {}, "NR": {},
+	"NU": {}, "PA": {}, "PE": {}, "PS": {}, "QA": {}, "RW": {}, "SB": {}, "SC": {}, "SD": {}, "SL": {},
+	"SR": {}, "SS": {}, "ST": {}, "SV": {}, "SX": {}, "SY": {}, "TD": {}, "TF": {}, "TG": {}, "TK": {},
+	"TL": {}, "TO": {}, "TT": {}, "TV": {}, "TZ": {}, "UG": {}, "UM": {}, "VC": {}, "VG": {}, "VU": {},
+	"WS": {}, "YE": {}, "ZW": {},
+}
+
+// isPostcode reports whether postcode is a valid postal code in country, using the
+// postcode_iso3166_alpha2 validation of v. Any postal code is valid in the
+// countries listed in countriesWithoutPostcodeFormat.
+func isPostcode(v *validator.Validate, postcode, country string) bool {
+	if _, ok := countriesWithoutPostcodeFormat[country]; ok {
+		return true
+	}
+
+	return v.Var(postcode, "postcode_iso3166_alpha2="+country) == nil
+}
+
+// isPostcodeOfCountryField implements the postcode_iso3166_alpha2_field validation
+// with v: the value must be a valid postal code in the country held by the field
+// named by the parameter, e.g. "postcode_iso3166_alpha2_field=Country".
+func isPostcodeOfCountryField(v *validator.Validate, fl validator.FieldLevel) bool {
+	country, kind, _, found := fl.GetStructFieldOKAdvanced2(fl.Parent(), fl.Param())
+	if !found || kind != reflect.String {
+		return false
+	}
+
+	return isPostcode(v, fl.Field().String(), country.String())
+}
+
+// isPostalAddress implements the postal_address validation with v. Addresses
+// without a postal code or without a country to check it against are valid.
+func isPostalAddress(v *validator.Validate, fl validator.FieldLevel) bool {
+	address := models.ParseAddress(fl.Field().String())
+	if address.PostalCode == "" || address.Country == "" {
+		return true
+	}
+
+	return isPostcode(v, address.PostalCode, address.Country)
+}
+
+// userServiceKey is the context key of the services.UserService normalising the
+// phone numbers checked by the phone validation.
+type userServiceKey struct{}
+
+// isPhone implements the phone validation. Without a service in ctx no number is valid.
+func isPhone(ctx context.Context, fl validator.FieldLevel) bool {
+	service, ok := ctx.Value(userServiceKey{}).(services.UserService)
+	if !ok {
+		return false
+	}
+	_, err := service.NormalizePhone(fl.Field().String())
+
+	return err == nil
+}
+
+// validateRequest validates the bound request body req, checking phone numbers
+// with the handler's service. If validation fails, it writes an HTTP 400 Bad
+// Request response listing the rejected fields and returns false.
+func (h *UserHandler) validateRequest(c *gin.Context, req any) bool {
+	ctx := context.WithValue(c.Request.Context(), userServiceKey{}, h.service)
+	if err := validate.StructCtx(ctx, req); err != nil {
+		abortWithValidationErrors(c, err)
+
+		return false
+	}
+
+	return true
+}
+
+// abortWithValidationErrors aborts the request with an HTTP 400 Bad Request problem
+// listing the fields rejected by validate.Struct or validate.StructCtx in err, in
+// the language selected by the Accept-Language header (see messageTranslator).
+func abortWithValidationErrors(c *gin.Context, err error) {
+	middleware.AbortWithProblem(c, problem.New(http.StatusBadRequest, "The request body failed validation").
+		WithErrors(formatValidationErrors(err, messageTranslator(c))))
+}
 
 // formatValidationErrors is a helper function that converts validation errors
-// returned by the 'go-playground/validator' library into a user-friendly map format,
-// suitable for returning as a JSON error response body in the API.
+// returned by the 'go-playground/validator' library into the field errors of a
+// problem.Problem, suitable for returning in the API's error response body.
 //
 // If the input error is of type validator.ValidationErrors, it iterates through
-// each field error, creating a descriptive message based on the validation tag
-// (e.g., "required", "email", "min"). The map keys are the field names and
-// the values are the corresponding error messages.
+// each field error, translating the message of the failed validation tag (e.g.,
+// "required", "email", "min") with trans. Fields are named by their path of JSON
+// keys from the request body, e.g. "contact.email" or "address.lines[0]". The
+// field errors are listed in the order of the struct fields.
 //
-// If the input error is not a validator.ValidationErrors, it returns a generic
-// error message under the "error" key.
+// If the input error is not a validator.ValidationErrors, it returns a single
+// generic field error for the "body" field.
 //
 // Parameters:
-//   - err: The error returned by the call to validate.Struct().
+//   - err: The error returned by the call to validate.Struct() or validate.StructCtx().
+//   - trans: The translator of the messages, one of translators.
 //
 // Returns:
-//   - A map[string]string where keys are field names (or "error") and values
-//     are user-readable validation error messages.
-func formatValidationErrors(err error) map[string]string {
-	errorsMap := make(map[string]string)
+//   - A slice of problem.FieldError holding the field names (or "body") and
+//     user-readable validation error messages.
+func formatValidationErrors(err error, trans ut.Translator) []problem.FieldError {
+	var fieldErrors []problem.FieldError
 	var validationErrs validator.ValidationErrors
 
 	// Use errors.As for type assertion, which is generally preferred over direct type assertion.
 	if errors.As(err, &validationErrs) {
 		for _, fieldErr := range validationErrs {
-			// Use fieldErr.Field() for the field name and create a user-friendly message
-			// based on fieldErr.Tag()
-			fieldName := fieldErr.Field()
-			switch fieldErr.Tag() {
-			case "required":
-				errorsMap[fieldName] = fmt.Sprintf("%s is required", fieldName)
-			case "email":
-				errorsMap[fieldName] = fmt.Sprintf("%s must be a valid email address", fieldName)
-			case "min":
-				errorsMap[fieldName] = fmt.Sprintf("%s must be at least %s characters long", fieldName, fieldErr.Param())
-			// Add more cases for other validation tags you use
-			default:
-				errorsMap[fieldName] = fmt.Sprintf("Invalid value for %s (%s)", fieldName, fieldErr.Tag())
+			// The namespace starts with the name of the request struct, which clients never see
+			_, fieldName, _ := strings.Cut(fieldErr.Namespace(), ".")
+			detail := fieldErr.Translate(trans)
+			if detail == fieldErr.Error() {
+				// Translate falls back to the Go error for tags without a message
+				detail = fmt.Sprintf("Invalid value for %s (%s)", fieldErr.Field(), fieldErr.Tag())
 			}
+			fieldErrors = append(fieldErrors, problem.FieldError{Field: fieldName, Detail: detail})
 		}
 	} else {
 		// Handle cases where the error is not from the validator (less common after ShouldBindJSON)
-		errorsMap["error"] = "Invalid request data structure" // More specific than generic "Invalid request data"
-		// Optionally log the actual error `err` here for debugging
-		// log.Printf("Non-validation error during request processing: %v", err)
+		fieldErrors = append(fieldErrors, problem.FieldError{Field: "body", Detail: "Invalid request data structure"})
 	}
 
-	return errorsMap
+	return fieldErrors
 }
@@ -1,13 +1,9 @@
 package handlers
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-
-	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
 )
 
 // DeleteUser handles HTTP DELETE requests to the /users/:id endpoint.
@@ -20,22 +16,21 @@ import (
 // @Description	remove user from the store by ID string from path parameter
 // @Tags			users
 // @Accept			json
-// @Produce		json
-// @Param			id	path		string				true	"User ID (UUID)"	Format(uuid)
-// @Success		204	{object}	nil					"Successfully deleted user (No Content)"
-// @Failure		404	{object}	map[string]string	"User not found"
-// @Failure		500	{object}	map[string]string	"Internal Server Error"
-// @Router			/users/{id} [delete]
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			id	path		string			true	"User ID (UUID)"	Format(uuid)
+// @Success		204	{object}	nil				"Successfully deleted user (No Content)"
+// @Failure		404	{object}	problem.Problem	"User not found"
+// @Failure		406	{object}	problem.Problem	"No acceptable representation"
+// @Failure		500	{object}	problem.Problem	"Internal Server Error"
+// @Failure		503	{object}	problem.Problem	"User store unavailable"
+// @Failure		504	{object}	problem.Problem	"Request timed out"
+// @Router			/v1/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
 
-	err := h.service.DeleteUser(userID)
+	err := h.service.DeleteUser(c.Request.Context(), userID)
 	if err != nil {
-		if errors.Is(err, services.ErrUserNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("User with ID '%s' not found", userID)})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
-		}
+		abortWithServiceError(c, err, "Failed to delete user")
 
 		return
 	}
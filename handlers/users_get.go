@@ -8,25 +8,52 @@ import (
 
 // GetUsers handles HTTP GET requests to the /users endpoint.
 // It retrieves all users by calling the UserService's GetUsers method.
-// On success, it responds with HTTP 200 OK and a JSON array of user objects.
+// On success, it responds with HTTP 200 OK and a JSON array of user objects,
+// projected to the fields selected by the `fields` query parameter, if any.
+// The optional page and per_page query parameters select a page of the list.
+// Clients accepting a media type with the parameter `envelope=v1` receive the
+// page wrapped in a ListResponse, with the total count and paging links.
+// An invalid selection or page is answered with HTTP 400 Bad Request.
 // On failure, it responds with HTTP 500 Internal Server Error and a JSON error message.
 // @Summary		List all users
-// @Description	get all users currently stored
+// @Description	get all users currently stored, or a page of them. Accepting a media type with the parameter envelope=v1 (e.g. "application/json; envelope=v1") wraps the users in {"data": [...], "meta": {"total", "page"}, "links": {...}}
 // @Tags			users
 // @Accept			json
-// @Produce		json
-// @Success		200	{array}		models.User			"Successfully retrieved list of users"
-// @Failure		500	{object}	map[string]string	"Internal Server Error"
-// @Router			/users [get]
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			fields		query		string			false	"Comma separated fields to return, e.g. id,first_name,preferences.sms"
+// @Param			page		query		int				false	"1-based page number"				minimum(1)	default(1)
+// @Param			per_page	query		int				false	"Page size, all users when omitted"	minimum(1)	maximum(100)
+// @Success		200			{array}		models.UserV1	"Successfully retrieved list of users"
+// @Failure		400			{object}	problem.Problem	"Invalid fields or paging parameters"
+// @Failure		406			{object}	problem.Problem	"No acceptable representation"
+// @Failure		500			{object}	problem.Problem	"Internal Server Error"
+// @Failure		503			{object}	problem.Problem	"User store unavailable"
+// @Failure		504			{object}	problem.Problem	"Request timed out"
+// @Router			/v1/users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	users, err := h.service.GetUsers()
+	fields, ok := h.userFields(c)
+	if !ok {
+		return
+	}
+	p, ok := pageFromQuery(c)
+	if !ok {
+		return
+	}
+
+	users, err := h.service.GetUsers(c.Request.Context())
 	if err != nil {
-		// Log the actual error internally if possible
-		// log.Error().Err(err).Msg("Failed to retrieve users from service")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
+		abortWithServiceError(c, err, "Failed to retrieve users")
+
+		return
+	}
+
+	start, end := p.bounds(len(users))
+	data := fields.Project(h.version.renderList(users[start:end]))
+	if !wantsEnvelope(c) {
+		respond(c, http.StatusOK, data)
 
 		return
 	}
 
-	c.JSON(http.StatusOK, users)
+	respond(c, http.StatusOK, newListResponse(c, p, len(users), end-start, data))
 }
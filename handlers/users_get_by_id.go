@@ -1,44 +1,47 @@
 package handlers
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-
-	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
 )
 
 // GetUserByID handles HTTP GET requests to the /users/:id endpoint.
 // It extracts the user ID from the URL path parameter.
 // It retrieves the specific user by calling the UserService's GetUserByID method.
-// On success, it responds with HTTP 200 OK and a JSON object representing the user.
+// On success, it responds with HTTP 200 OK and a JSON object representing the user,
+// projected to the fields selected by the `fields` query parameter, if any.
+// An invalid selection is answered with HTTP 400 Bad Request.
 // If the user is not found, it responds with HTTP 404 Not Found.
 // For other errors, it responds with HTTP 500 Internal Server Error.
 // @Summary		Get a single user by ID
 // @Description	get user by ID string from path parameter
 // @Tags			users
 // @Accept			json
-// @Produce		json
-// @Param			id	path		string				true	"User ID (UUID)"	Format(uuid)
-// @Success		200	{object}	models.User			"Successfully retrieved user"
-// @Failure		404	{object}	map[string]string	"User not found"
-// @Failure		500	{object}	map[string]string	"Internal Server Error"
-// @Router			/users/{id} [get]
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			id		path		string			true	"User ID (UUID)"	Format(uuid)
+// @Param			fields	query		string			false	"Comma separated fields to return, e.g. id,first_name,preferences.sms"
+// @Success		200		{object}	models.UserV1	"Successfully retrieved user"
+// @Failure		400		{object}	problem.Problem	"Invalid fields parameter"
+// @Failure		404		{object}	problem.Problem	"User not found"
+// @Failure		406		{object}	problem.Problem	"No acceptable representation"
+// @Failure		500		{object}	problem.Problem	"Internal Server Error"
+// @Failure		503		{object}	problem.Problem	"User store unavailable"
+// @Failure		504		{object}	problem.Problem	"Request timed out"
+// @Router			/v1/users/{id} [get]
 func (h *UserHandler) GetUserByID(c *gin.Context) {
 	userID := c.Param("id")
+	fields, ok := h.userFields(c)
+	if !ok {
+		return
+	}
 
-	user, err := h.service.GetUserByID(userID)
+	user, err := h.service.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
-		if errors.Is(err, services.ErrUserNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("User with ID '%s' not found", userID)})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
-		}
+		abortWithServiceError(c, err, "Failed to retrieve user")
 
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respond(c, http.StatusOK, fields.Project(h.version.render(*user)))
 }
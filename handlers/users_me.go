@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/router/middleware"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+)
+
+// UpdateMeRequest defines the expected JSON payload structure for replacing the
+// caller's own profile using the PUT method. Only profile fields and Preferences
+// can be changed; Email and Active are tied to the caller's identity and account
+// status and are therefore not part of the self-service payload.
+type UpdateMeRequest struct {
+	// FirstName is the user's given name. (Required)
+	FirstName string `json:"first_name" xml:"first_name" validate:"required,min=1"`
+	// LastName is the user's family name or surname. (Required)
+	LastName string `json:"last_name" xml:"last_name" validate:"required,min=1"`
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code. (Required)
+	Phone string `json:"phone" xml:"phone" validate:"required,phone"`
+	// Address is the user's physical address as a single line of text, parsed into
+	// its parts with models.ParseAddress. A postal code must be valid in the country. (Required)
+	Address string `json:"address" xml:"address" validate:"required,postal_address"`
+	// Preferences contains the user's notification settings (Email/SMS).
+	Preferences models.Preferences `json:"preferences" xml:"preferences"`
+}
+
+// PatchPreferencesRequest holds optional notification settings for a partial update.
+// Nil fields are left unchanged.
+type PatchPreferencesRequest struct {
+	// Email indicates if the user wants email notifications.
+	Email *bool `json:"email" xml:"email"`
+	// SMS indicates if the user wants SMS text message notifications.
+	SMS *bool `json:"sms" xml:"sms"`
+}
+
+// PatchMeRequest defines the expected JSON payload structure for partially updating
+// the caller's own profile using the PATCH method. Every field is optional; nil
+// fields are left unchanged.
+type PatchMeRequest struct {
+	// FirstName is the user's given name.
+	FirstName *string `json:"first_name" xml:"first_name" validate:"omitempty,min=1"`
+	// LastName is the user's family name or surname.
+	LastName *string `json:"last_name" xml:"last_name" validate:"omitempty,min=1"`
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code.
+	Phone *string `json:"phone" xml:"phone" validate:"omitempty,phone"`
+	// Address is the user's physical address as a single line of text, parsed into
+	// its parts with models.ParseAddress. A postal code must be valid in the country.
+	Address *string `json:"address" xml:"address" validate:"omitempty,min=1,postal_address"`
+	// Preferences contains the user's notification settings (Email/SMS).
+	Preferences *PatchPreferencesRequest `json:"preferences" xml:"preferences"`
+}
+
+// apply replaces the profile fields and Preferences of user.
+func (req *UpdateMeRequest) apply(user *models.User) {
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	user.Phone = req.Phone
+	user.Address = models.ParseAddress(req.Address)
+	user.Preferences = req.Preferences
+}
+
+// apply sets the fields of user that are present in the request.
+func (req *PatchMeRequest) apply(user *models.User) {
+	if req.FirstName != nil {
+		user.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		user.LastName = *req.LastName
+	}
+	if req.Phone != nil {
+		user.Phone = *req.Phone
+	}
+	if req.Address != nil {
+		user.Address = models.ParseAddress(*req.Address)
+	}
+	if req.Preferences != nil {
+		req.Preferences.apply(&user.Preferences)
+	}
+}
+
+// apply sets the notification settings of preferences that are present in the request.
+func (req *PatchPreferencesRequest) apply(preferences *models.Preferences) {
+	if req.Email != nil {
+		preferences.Email = *req.Email
+	}
+	if req.SMS != nil {
+		preferences.SMS = *req.SMS
+	}
+}
+
+// GetMe handles HTTP GET requests to the /users/me endpoint.
+// It resolves the caller's identity, as set by the configured authentication
+// middleware, to a user record via the identity's email address.
+// On success, it responds with HTTP 200 OK and a JSON object representing the user,
+// projected to the fields selected by the `fields` query parameter, if any.
+// An invalid selection is answered with HTTP 400 Bad Request.
+// If no identity is present, it responds with HTTP 401 Unauthorized.
+// If no user matches the identity, it responds with HTTP 404 Not Found.
+// @Summary		Get the caller's own user
+// @Description	get the user record matching the authenticated caller's email
+// @Tags			users
+// @Accept			json
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			fields	query		string			false	"Comma separated fields to return, e.g. id,first_name,preferences.sms"
+// @Success		200		{object}	models.UserV1	"Successfully retrieved user"
+// @Failure		400		{object}	problem.Problem	"Invalid fields parameter"
+// @Failure		401		{object}	problem.Problem	"No authenticated identity"
+// @Failure		404		{object}	problem.Problem	"No user for the authenticated identity"
+// @Failure		406		{object}	problem.Problem	"No acceptable representation"
+// @Failure		500		{object}	problem.Problem	"Internal Server Error"
+// @Failure		503		{object}	problem.Problem	"User store unavailable"
+// @Failure		504		{object}	problem.Problem	"Request timed out"
+// @Router			/v1/users/me [get]
+func (h *UserHandler) GetMe(c *gin.Context) {
+	fields, ok := h.userFields(c)
+	if !ok {
+		return
+	}
+
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	respond(c, http.StatusOK, fields.Project(h.version.render(*user)))
+}
+
+// UpdateMe handles HTTP PUT requests to the /users/me endpoint.
+// It resolves the caller's user record, binds and validates the incoming JSON
+// request body to an UpdateMeRequest struct, and replaces the caller's profile
+// fields and Preferences. Email and Active are preserved from the stored record.
+// On successful update, it responds with HTTP 200 OK and the updated user.
+// @Summary		Replace the caller's own profile
+// @Description	update profile fields and preferences of the authenticated caller (PUT semantics)
+// @Tags			users
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			user	body		handlers.UpdateMeRequest	true	"Profile data to update"
+// @Success		200		{object}	models.UserV1				"Successfully updated user"
+// @Failure		400		{object}	problem.Problem				"Validation Error or Invalid Request Format"
+// @Failure		406		{object}	problem.Problem				"No acceptable representation"
+// @Failure		413		{object}	problem.Problem				"Request body too large"
+// @Failure		415		{object}	problem.Problem				"Unsupported Content-Type"
+// @Failure		401		{object}	problem.Problem				"No authenticated identity"
+// @Failure		404		{object}	problem.Problem				"No user for the authenticated identity"
+// @Failure		500		{object}	problem.Problem				"Internal Server Error"
+// @Failure		503		{object}	problem.Problem				"User store unavailable"
+// @Failure		504		{object}	problem.Problem				"Request timed out"
+// @Router			/v1/users/me [put]
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	req := h.version.newUpdateMeRequest()
+	if !bindBody(c, req) {
+		return
+	}
+
+	if !h.validateRequest(c, req) {
+		return
+	}
+
+	h.saveMe(c, user.ID, req.apply)
+}
+
+// PatchMe handles HTTP PATCH requests to the /users/me endpoint.
+// It resolves the caller's user record, binds and validates the incoming JSON
+// request body to a PatchMeRequest struct, and applies only the fields that
+// were provided. Email and Active cannot be changed through this endpoint.
+// On successful update, it responds with HTTP 200 OK and the updated user.
+// @Summary		Partially update the caller's own profile
+// @Description	update selected profile fields and preferences of the authenticated caller (PATCH semantics)
+// @Tags			users
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			user	body		handlers.PatchMeRequest	true	"Profile fields to update"
+// @Success		200		{object}	models.UserV1			"Successfully updated user"
+// @Failure		400		{object}	problem.Problem			"Validation Error or Invalid Request Format"
+// @Failure		406		{object}	problem.Problem			"No acceptable representation"
+// @Failure		413		{object}	problem.Problem			"Request body too large"
+// @Failure		415		{object}	problem.Problem			"Unsupported Content-Type"
+// @Failure		401		{object}	problem.Problem			"No authenticated identity"
+// @Failure		404		{object}	problem.Problem			"No user for the authenticated identity"
+// @Failure		500		{object}	problem.Problem			"Internal Server Error"
+// @Failure		503		{object}	problem.Problem			"User store unavailable"
+// @Failure		504		{object}	problem.Problem			"Request timed out"
+// @Router			/v1/users/me [patch]
+func (h *UserHandler) PatchMe(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+
+	req := h.version.newPatchMeRequest()
+	if !bindBody(c, req) {
+		return
+	}
+
+	if !h.validateRequest(c, req) {
+		return
+	}
+
+	h.saveMe(c, user.ID, req.apply)
+}
+
+// currentUser resolves the caller's identity to the matching user record.
+// If the identity is missing or cannot be resolved, it writes the appropriate
+// error response and returns false.
+func (h *UserHandler) currentUser(c *gin.Context) (*models.User, bool) {
+	identity, ok := middleware.IdentityFrom(c)
+	if !ok {
+		middleware.AbortWithError(c, http.StatusUnauthorized, "Authentication required")
+
+		return nil, false
+	}
+
+	user, err := h.service.GetUserByEmail(c.Request.Context(), identity.Email)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			middleware.AbortWithError(c, http.StatusNotFound, "No user found for the authenticated identity")
+		} else {
+			abortWithServiceError(c, err, "Failed to retrieve user")
+		}
+
+		return nil, false
+	}
+
+	return user, true
+}
+
+// saveMe applies update to the caller's record, identified by id, through the
+// UserService and writes the response. The update is applied to the stored record
+// rather than the one read by currentUser, so concurrent changes to fields the
+// request does not set, such as Email and Active, are kept.
+func (h *UserHandler) saveMe(c *gin.Context, id string, update func(user *models.User)) {
+	updated, err := h.service.UpdateUserFields(c.Request.Context(), id, update)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			middleware.AbortWithError(c, http.StatusNotFound, "No user found for the authenticated identity")
+		} else {
+			abortWithServiceError(c, err, "Failed to update user")
+		}
+
+		return
+	}
+
+	respond(c, http.StatusOK, h.version.render(*updated))
+}
@@ -14,17 +14,29 @@ import (
 // to true within the handler logic.
 type CreateUserRequest struct {
 	// FirstName is the user's given name. (Required)
-	FirstName string `json:"first_name" validate:"required,min=1"`
+	FirstName string `json:"first_name" xml:"first_name" validate:"required,min=1"`
 	// LastName is the user's family name or surname. (Required)
-	LastName string `json:"last_name" validate:"required,min=1"`
+	LastName string `json:"last_name" xml:"last_name" validate:"required,min=1"`
 	// Email is the user's unique email address. (Required, must be valid email format)
-	Email string `json:"email" validate:"required,email"`
-	// Phone is the user's primary phone number. (Required)
-	Phone string `json:"phone" validate:"required"`
-	// Address is the user's physical address. (Required)
-	Address string `json:"address" validate:"required"`
+	Email string `json:"email" xml:"email" validate:"required,email"`
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code. (Required)
+	Phone string `json:"phone" xml:"phone" validate:"required,phone"`
+	// Address is the user's physical address as a single line of text, parsed into
+	// its parts with models.ParseAddress. A postal code must be valid in the country. (Required)
+	Address string `json:"address" xml:"address" validate:"required,postal_address"`
 	// Preferences contains the user's notification settings (Email/SMS).
-	Preferences models.Preferences `json:"preferences"`
+	Preferences models.Preferences `json:"preferences" xml:"preferences"`
+}
+
+// apply copies the fields of the request onto user.
+func (req *CreateUserRequest) apply(user *models.User) {
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	user.Email = req.Email
+	user.Phone = req.Phone
+	user.Address = models.ParseAddress(req.Address)
+	user.Preferences = req.Preferences
 }
 
 // CreateUser handles HTTP POST requests to the /users endpoint.
@@ -35,47 +47,46 @@ type CreateUserRequest struct {
 // (setting Active to true by default) and calls the UserService's CreateUser method.
 // On successful creation, it responds with HTTP 201 Created and a JSON object
 // representing the newly created user (including system-generated fields like ID).
+// If the email address belongs to another user, it responds with HTTP 409 Conflict.
 // On failure during user creation, it responds with HTTP 500 Internal Server Error.
+// Requests carrying an Idempotency-Key header are deduplicated by middleware.Idempotency.
 // @Summary		Create a new user
 // @Description	add a new user to the store based on JSON payload
 // @Tags			users
-// @Accept			json
-// @Produce		json
-// @Param			user	body		handlers.CreateUserRequest	true	"User data to create"
-// @Success		201		{object}	models.User					"Successfully created user"
-// @Failure		400		{object}	map[string]any				"Validation Error or Invalid Request Format"
-// @Failure		500		{object}	map[string]string			"Internal Server Error"
-// @Router			/users [post]
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			user			body		handlers.CreateUserRequest	true	"User data to create"
+// @Param			Idempotency-Key	header		string						false	"Client generated key making retries safe"
+// @Success		201				{object}	models.UserV1				"Successfully created user"
+// @Failure		400				{object}	problem.Problem				"Validation Error or Invalid Request Format"
+// @Failure		406				{object}	problem.Problem				"No acceptable representation"
+// @Failure		409				{object}	problem.Problem				"Email address already in use, or a request with the same Idempotency-Key is in progress"
+// @Failure		413				{object}	problem.Problem				"Request body too large"
+// @Failure		415				{object}	problem.Problem				"Unsupported Content-Type"
+// @Failure		422				{object}	problem.Problem				"Idempotency-Key reused with a different body"
+// @Failure		500				{object}	problem.Problem				"Internal Server Error"
+// @Failure		503				{object}	problem.Problem				"User store unavailable"
+// @Failure		504				{object}	problem.Problem				"Request timed out"
+// @Router			/v1/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	var req CreateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
-
+	req := h.version.newCreateRequest()
+	if !bindBody(c, req) {
 		return
 	}
 
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"validation_errors": formatValidationErrors(err)})
-
+	if !h.validateRequest(c, req) {
 		return
 	}
 
-	newUser := models.User{
-		FirstName:   req.FirstName,
-		LastName:    req.LastName,
-		Email:       req.Email,
-		Phone:       req.Phone,
-		Address:     req.Address, // Assuming string address model
-		Active:      true,
-		Preferences: req.Preferences,
-	}
+	newUser := models.User{Active: true}
+	req.apply(&newUser)
 
-	createdUser, err := h.service.CreateUser(newUser)
+	createdUser, err := h.service.CreateUser(c.Request.Context(), newUser)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		abortWithServiceError(c, err, "Failed to create user")
 
 		return
 	}
 
-	c.JSON(http.StatusCreated, createdUser)
+	respond(c, http.StatusCreated, h.version.render(*createdUser))
 }
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+)
+
+func TestAbortWithServiceError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantStatus     int
+		wantDetail     string
+		wantCode       string
+		wantRetryAfter string
+	}{
+		{
+			name:       "not found",
+			err:        fmt.Errorf("lookup: %w", &services.Error{Code: services.CodeNotFound, Message: "user with ID '42' not found"}),
+			wantStatus: http.StatusNotFound,
+			wantDetail: "user with ID '42' not found",
+			wantCode:   "not_found",
+		},
+		{
+			name:       "conflict",
+			err:        services.ErrEmailTaken,
+			wantStatus: http.StatusConflict,
+			wantDetail: services.ErrEmailTaken.Message,
+			wantCode:   "conflict",
+		},
+		{
+			name:       "validation",
+			err:        &services.Error{Code: services.CodeValidation, Message: "phone number is not valid", Err: errors.New("too short")},
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "phone number is not valid",
+			wantCode:   "validation",
+		},
+		{
+			name:           "unavailable",
+			err:            &services.Error{Code: services.CodeUnavailable, Message: "user store unavailable", Err: context.Canceled},
+			wantStatus:     http.StatusServiceUnavailable,
+			wantDetail:     "user store unavailable",
+			wantCode:       "unavailable",
+			wantRetryAfter: "1",
+		},
+		{
+			name:       "deadline exceeded",
+			err:        &services.Error{Code: services.CodeUnavailable, Message: "user store unavailable", Err: context.DeadlineExceeded},
+			wantStatus: http.StatusGatewayTimeout,
+			wantDetail: "The request did not complete in time",
+		},
+		{
+			name:       "unknown code",
+			err:        &services.Error{Code: "teapot", Message: "short and stout"},
+			wantStatus: http.StatusInternalServerError,
+			wantDetail: "Failed to load user",
+		},
+		{
+			name:       "unexpected error",
+			err:        errors.New("disk on fire"),
+			wantStatus: http.StatusInternalServerError,
+			wantDetail: "Failed to load user",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/", func(c *gin.Context) { abortWithServiceError(c, tt.err, "Failed to load user") })
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var got problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			if rec.Code != tt.wantStatus || got.Status != tt.wantStatus {
+				t.Errorf("status = %d (problem %d), want %d", rec.Code, got.Status, tt.wantStatus)
+			}
+			if got.Detail != tt.wantDetail || got.Code != tt.wantCode {
+				t.Errorf("detail, code = %q, %q, want %q, %q", got.Detail, got.Code, tt.wantDetail, tt.wantCode)
+			}
+			if retryAfter := rec.Header().Get("Retry-After"); retryAfter != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", retryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestPostalCodeValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     any
+		wantTag string // empty: valid
+	}{
+		{
+			name: "v2 valid",
+			req:  &AddressRequest{Lines: []string{"Karl Johans gate 1"}, City: "Oslo", PostalCode: "0154", Country: "NO"},
+		},
+		{
+			name: "v2 without postal code",
+			req:  &AddressRequest{Lines: []string{"1 Queen's Road"}, City: "Hong Kong", Country: "HK"},
+		},
+		{
+			name: "v2 country without a known format",
+			req:  &AddressRequest{Lines: []string{"1 Grafton Street"}, City: "Dublin", PostalCode: "D02 X285", Country: "IE"},
+		},
+		{
+			name: "v2 country without postal codes",
+			req:  &AddressRequest{Lines: []string{"1 Queen's Road"}, City: "Hong Kong", PostalCode: "000000", Country: "HK"},
+		},
+		{
+			name:    "v2 wrong format",
+			req:     &AddressRequest{Lines: []string{"Karl Johans gate 1"}, City: "Oslo", PostalCode: "01540", Country: "NO"},
+			wantTag: "postcode_iso3166_alpha2_field",
+		},
+		{
+			name:    "v2 code of another country",
+			req:     &AddressRequest{Lines: []string{"1 Main St"}, City: "Springfield", PostalCode: "SW1A 2AA", Country: "US"},
+			wantTag: "postcode_iso3166_alpha2_field",
+		},
+		{
+			name: "v1 valid",
+			req:  &PatchMeRequest{Address: ptr("1 Main St, Springfield, IL 62701, USA")},
+		},
+		{
+			name: "v1 without country",
+			req:  &PatchMeRequest{Address: ptr("1 Main St, Springfield, IL 62701")},
+		},
+		{
+			name: "v1 without postal code",
+			req:  &PatchMeRequest{Address: ptr("1 Main St, Springfield, IL")},
+		},
+		{
+			name: "v1 country without a known format",
+			req:  &PatchMeRequest{Address: ptr("Carrera 7 32-16, Bogotá 110311, CO")},
+		},
+		{
+			name: "v1 country without postal codes",
+			req:  &PatchMeRequest{Address: ptr("1 Sheikh Zayed Road, Dubai 00000, AE")},
+		},
+		{
+			name:    "v1 wrong format",
+			req:     &PatchMeRequest{Address: ptr("Karl Johans gate 1, 01540 Oslo, Norway")},
+			wantTag: "postal_address",
+		},
+		{
+			name:    "v1 code of another country",
+			req:     &PatchMeRequest{Address: ptr("1 Main St, Springfield, IL 6270, US")},
+			wantTag: "postal_address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.req)
+			if tt.wantTag == "" {
+				if err != nil {
+					t.Errorf("Struct() error = %v, want valid", err)
+				}
+
+				return
+			}
+
+			var validationErrors validator.ValidationErrors
+			if !errors.As(err, &validationErrors) || len(validationErrors) != 1 || validationErrors[0].Tag() != tt.wantTag {
+				t.Errorf("Struct() error = %v, want a single %s error", err, tt.wantTag)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestValidateRequestChecksPhonesWithService(t *testing.T) {
+	tests := []struct {
+		region string
+		number string
+		want   bool
+	}{
+		{region: "NO", number: "22 33 44 55", want: true},
+		{region: "US", number: "22 33 44 55", want: false},
+		{region: "US", number: "(201) 555-0123", want: true},
+		{region: "NO", number: "+1 201 555 0123", want: true},
+		{region: "NO", number: "12", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region+" "+tt.number, func(t *testing.T) {
+			handler := NewUserHandler(services.NewUserService(nil, tt.region))
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/v1/users/me", nil)
+
+			if got := handler.validateRequest(c, &PatchMeRequest{Phone: ptr(tt.number)}); got != tt.want {
+				t.Errorf("validateRequest() = %t, want %t (status %d)", got, tt.want, rec.Code)
+			}
+		})
+	}
+}
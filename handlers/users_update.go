@@ -1,14 +1,11 @@
 package handlers
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
-	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
 )
 
 // UpdateUserRequest defines the expected JSON payload structure for updating an existing user
@@ -17,19 +14,32 @@ import (
 // validation tags (like 'omitempty') would typically be used.
 type UpdateUserRequest struct {
 	// FirstName is the user's given name. (Required)
-	FirstName string `json:"first_name" validate:"required,min=1"`
+	FirstName string `json:"first_name" xml:"first_name" validate:"required,min=1"`
 	// LastName is the user's family name or surname. (Required)
-	LastName string `json:"last_name" validate:"required,min=1"`
+	LastName string `json:"last_name" xml:"last_name" validate:"required,min=1"`
 	// Email is the user's unique email address. (Required, must be valid email format)
-	Email string `json:"email" validate:"required,email"`
-	// Phone is the user's primary phone number. (Required)
-	Phone string `json:"phone" validate:"required"`
-	// Address is the user's physical address. (Required)
-	Address string `json:"address" validate:"required"`
+	Email string `json:"email" xml:"email" validate:"required,email"`
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code. (Required)
+	Phone string `json:"phone" xml:"phone" validate:"required,phone"`
+	// Address is the user's physical address as a single line of text, parsed into
+	// its parts with models.ParseAddress. A postal code must be valid in the country. (Required)
+	Address string `json:"address" xml:"address" validate:"required,postal_address"`
 	// Active indicates whether the user's account should be active.
-	Active bool `json:"active"`
+	Active bool `json:"active" xml:"active"`
 	// Preferences contains the user's notification settings (Email/SMS).
-	Preferences models.Preferences `json:"preferences"`
+	Preferences models.Preferences `json:"preferences" xml:"preferences"`
+}
+
+// apply copies the fields of the request onto user.
+func (req *UpdateUserRequest) apply(user *models.User) {
+	user.FirstName = req.FirstName
+	user.LastName = req.LastName
+	user.Email = req.Email
+	user.Phone = req.Phone
+	user.Address = models.ParseAddress(req.Address)
+	user.Active = req.Active
+	user.Preferences = req.Preferences
 }
 
 // UpdateUser handles HTTP PUT requests to the /users/:id endpoint.
@@ -42,54 +52,45 @@ type UpdateUserRequest struct {
 // On successful update, it responds with HTTP 200 OK and a JSON object representing
 // the updated user.
 // If the user specified by the ID is not found, it responds with HTTP 404 Not Found.
+// If the email address belongs to another user, it responds with HTTP 409 Conflict.
 // For other update failures, it responds with HTTP 500 Internal Server Error.
 // @Summary		Update an existing user
 // @Description	update user data for the given ID based on JSON payload (PUT semantics)
 // @Tags			users
-// @Accept			json
-// @Produce		json
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
 // @Param			id		path		string						true	"User ID (UUID)"	Format(uuid)
 // @Param			user	body		handlers.UpdateUserRequest	true	"User data to update"
-// @Success		200		{object}	models.User					"Successfully updated user"
-// @Failure		400		{object}	map[string]any				"Validation Error or Invalid Request Format"
-// @Failure		404		{object}	map[string]string			"User not found"
-// @Failure		500		{object}	map[string]string			"Internal Server Error"
-// @Router			/users/{id} [put]
+// @Success		200		{object}	models.UserV1				"Successfully updated user"
+// @Failure		400		{object}	problem.Problem				"Validation Error or Invalid Request Format"
+// @Failure		406		{object}	problem.Problem				"No acceptable representation"
+// @Failure		409		{object}	problem.Problem				"Email address already in use"
+// @Failure		413		{object}	problem.Problem				"Request body too large"
+// @Failure		415		{object}	problem.Problem				"Unsupported Content-Type"
+// @Failure		404		{object}	problem.Problem				"User not found"
+// @Failure		500		{object}	problem.Problem				"Internal Server Error"
+// @Failure		503		{object}	problem.Problem				"User store unavailable"
+// @Failure		504		{object}	problem.Problem				"Request timed out"
+// @Router			/v1/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	userID := c.Param("id")
-	var req UpdateUserRequest
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format: " + err.Error()})
-
+	req := h.version.newUpdateRequest()
+	if !bindBody(c, req) {
 		return
 	}
 
-	if err := validate.Struct(req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"validation_errors": formatValidationErrors(err)})
-
+	if !h.validateRequest(c, req) {
 		return
 	}
 
-	updatedData := models.User{
-		FirstName:   req.FirstName,
-		LastName:    req.LastName,
-		Email:       req.Email,
-		Phone:       req.Phone,
-		Address:     req.Address,
-		Active:      req.Active,
-		Preferences: req.Preferences,
-	}
+	var updatedData models.User
+	req.apply(&updatedData)
 
-	user, err := h.service.UpdateUser(userID, updatedData)
+	user, err := h.service.UpdateUser(c.Request.Context(), userID, updatedData)
 	if err != nil {
-		if errors.Is(err, services.ErrUserNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("User with ID '%s' not found", userID)})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
-		}
+		abortWithServiceError(c, err, "Failed to update user")
 
 		return
 	}
-	c.JSON(http.StatusOK, user)
+	respond(c, http.StatusOK, h.version.render(*user))
 }
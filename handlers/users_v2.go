@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+)
+
+// NameRequest holds the name of a user in version 2 request payloads.
+type NameRequest struct {
+	// First is the user's given name. (Required)
+	First string `json:"first" xml:"first" validate:"required,min=1"`
+	// Last is the user's family name or surname. (Required)
+	Last string `json:"last" xml:"last" validate:"required,min=1"`
+}
+
+// ContactRequest holds the contact details of a user in version 2 request payloads.
+type ContactRequest struct {
+	// Email is the user's unique email address. (Required, must be valid email format)
+	Email string `json:"email" xml:"email" validate:"required,email"`
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code. (Required)
+	Phone string `json:"phone" xml:"phone" validate:"required,phone"`
+}
+
+// AddressRequest holds a postal address in version 2 request payloads. The postal
+// code is validated against the format of the country with the
+// postcode_iso3166_alpha2_field validation (see newValidator); coordinates are
+// resolved by the service.
+type AddressRequest struct {
+	// Lines holds the street address, one entry per line. (Required, 1 to 4 lines)
+	Lines []string `json:"lines" xml:"lines>line" validate:"required,min=1,max=4,dive,required,max=100"`
+	// City is the city, town or locality. (Required)
+	City string `json:"city" xml:"city" validate:"required,max=100"`
+	// Region is the state, province or county, where the country uses one.
+	Region string `json:"region" xml:"region" validate:"max=100"`
+	// PostalCode is the postal code, in the format of the country. Countries without
+	// a known format accept any postal code.
+	PostalCode string `json:"postal_code" xml:"postal_code" validate:"omitempty,max=16,postcode_iso3166_alpha2_field=Country"`
+	// Country is the ISO 3166-1 alpha-2 code of the country in upper case, e.g. "NO". (Required)
+	Country string `json:"country" xml:"country" validate:"required,iso3166_1_alpha2"`
+}
+
+// address returns the models.Address described by the request.
+func (req *AddressRequest) address() models.Address {
+	return models.Address{
+		Lines:      req.Lines,
+		City:       req.City,
+		Region:     req.Region,
+		PostalCode: req.PostalCode,
+		Country:    req.Country,
+	}
+}
+
+// CreateUserRequestV2 defines the expected payload structure for creating a new
+// user in version 2 of the API. Like CreateUserRequest, new users are active.
+type CreateUserRequestV2 struct {
+	// Name holds the user's given and family names. (Required)
+	Name NameRequest `json:"name" xml:"name"`
+	// Contact holds the user's email address and phone number. (Required)
+	Contact ContactRequest `json:"contact" xml:"contact"`
+	// Address is the user's physical address. (Required)
+	Address AddressRequest `json:"address" xml:"address"`
+	// Notifications contains the user's notification settings (Email/SMS).
+	Notifications models.Preferences `json:"notifications" xml:"notifications"`
+}
+
+// apply copies the fields of the request onto user.
+func (req *CreateUserRequestV2) apply(user *models.User) {
+	user.FirstName = req.Name.First
+	user.LastName = req.Name.Last
+	user.Email = req.Contact.Email
+	user.Phone = req.Contact.Phone
+	user.Address = req.Address.address()
+	user.Preferences = req.Notifications
+}
+
+// UpdateUserRequestV2 defines the expected payload structure for replacing an
+// existing user in version 2 of the API (PUT semantics).
+type UpdateUserRequestV2 struct {
+	// Name holds the user's given and family names. (Required)
+	Name NameRequest `json:"name" xml:"name"`
+	// Contact holds the user's email address and phone number. (Required)
+	Contact ContactRequest `json:"contact" xml:"contact"`
+	// Address is the user's physical address. (Required)
+	Address AddressRequest `json:"address" xml:"address"`
+	// Status is the state of the user's account. (Required)
+	Status models.UserStatus `json:"status" xml:"status" validate:"required,oneof=active inactive" enums:"active,inactive"`
+	// Notifications contains the user's notification settings (Email/SMS).
+	Notifications models.Preferences `json:"notifications" xml:"notifications"`
+}
+
+// apply copies the fields of the request onto user.
+func (req *UpdateUserRequestV2) apply(user *models.User) {
+	user.FirstName = req.Name.First
+	user.LastName = req.Name.Last
+	user.Email = req.Contact.Email
+	user.Phone = req.Contact.Phone
+	user.Address = req.Address.address()
+	user.Active = req.Status == models.UserStatusActive
+	user.Preferences = req.Notifications
+}
+
+// MeContactRequest holds the contact details callers can change on their own
+// profile. The email address is tied to the caller's identity and cannot be changed.
+type MeContactRequest struct {
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code. (Required)
+	Phone string `json:"phone" xml:"phone" validate:"required,phone"`
+}
+
+// UpdateMeRequestV2 defines the expected payload structure for replacing the
+// caller's own profile in version 2 of the API (PUT semantics).
+type UpdateMeRequestV2 struct {
+	// Name holds the user's given and family names. (Required)
+	Name NameRequest `json:"name" xml:"name"`
+	// Contact holds the user's phone number. (Required)
+	Contact MeContactRequest `json:"contact" xml:"contact"`
+	// Address is the user's physical address. (Required)
+	Address AddressRequest `json:"address" xml:"address"`
+	// Notifications contains the user's notification settings (Email/SMS).
+	Notifications models.Preferences `json:"notifications" xml:"notifications"`
+}
+
+// apply replaces the profile fields and notification settings of user.
+func (req *UpdateMeRequestV2) apply(user *models.User) {
+	user.FirstName = req.Name.First
+	user.LastName = req.Name.Last
+	user.Phone = req.Contact.Phone
+	user.Address = req.Address.address()
+	user.Preferences = req.Notifications
+}
+
+// PatchNameRequest holds optional name parts for a partial update. Nil fields are left unchanged.
+type PatchNameRequest struct {
+	// First is the user's given name.
+	First *string `json:"first" xml:"first" validate:"omitempty,min=1"`
+	// Last is the user's family name or surname.
+	Last *string `json:"last" xml:"last" validate:"omitempty,min=1"`
+}
+
+// PatchMeContactRequest holds optional contact details for a partial update. Nil fields are left unchanged.
+type PatchMeContactRequest struct {
+	// Phone is the user's primary phone number, in any common format; it is stored in
+	// E.164 format. Numbers from outside the default region need the country calling code.
+	Phone *string `json:"phone" xml:"phone" validate:"omitempty,phone"`
+}
+
+// PatchMeRequestV2 defines the expected payload structure for partially updating
+// the caller's own profile in version 2 of the API (PATCH semantics). Every field
+// is optional; nil fields are left unchanged.
+type PatchMeRequestV2 struct {
+	// Name holds the user's given and family names.
+	Name *PatchNameRequest `json:"name" xml:"name"`
+	// Contact holds the user's phone number.
+	Contact *PatchMeContactRequest `json:"contact" xml:"contact"`
+	// Address replaces the user's physical address as a whole.
+	Address *AddressRequest `json:"address" xml:"address"`
+	// Notifications contains the user's notification settings (Email/SMS).
+	Notifications *PatchPreferencesRequest `json:"notifications" xml:"notifications"`
+}
+
+// apply sets the fields of user that are present in the request.
+func (req *PatchMeRequestV2) apply(user *models.User) {
+	if req.Name != nil {
+		if req.Name.First != nil {
+			user.FirstName = *req.Name.First
+		}
+		if req.Name.Last != nil {
+			user.LastName = *req.Name.Last
+		}
+	}
+	if req.Contact != nil && req.Contact.Phone != nil {
+		user.Phone = *req.Contact.Phone
+	}
+	if req.Address != nil {
+		user.Address = req.Address.address()
+	}
+	if req.Notifications != nil {
+		req.Notifications.apply(&user.Preferences)
+	}
+}
+
+// UserHandlerV2 serves version 2 of the /users endpoints, which represents users
+// as models.UserV2 and accepts the V2 request payloads. The endpoints behave like
+// their version 1 counterparts on UserHandler, which they delegate to; they are
+// declared separately to document the version 2 payloads.
+type UserHandlerV2 struct {
+	handler *UserHandler
+}
+
+// NewUserHandlerV2 creates a UserHandlerV2 backed by service.
+//
+// Parameters:
+//   - service: An instance implementing the services.UserService interface, as for
+//     NewUserHandler.
+//
+// Returns:
+//   - A pointer to a newly created UserHandlerV2 instance.
+func NewUserHandlerV2(service services.UserService) *UserHandlerV2 {
+	return &UserHandlerV2{
+		handler: &UserHandler{
+			service: service,
+			version: userV2,
+		},
+	}
+}
+
+// GetUsers handles HTTP GET requests to the /v2/users endpoint, listing users as
+// models.UserV2. See UserHandler.GetUsers.
+// @Summary		List all users
+// @Description	get all users currently stored, or a page of them. Accepting a media type with the parameter envelope=v1 (e.g. "application/json; envelope=v1") wraps the users in {"data": [...], "meta": {"total", "page"}, "links": {...}}
+// @Tags			users-v2
+// @Accept			json
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			fields		query		string			false	"Comma separated fields to return, e.g. id,name.first,notifications.sms"
+// @Param			page		query		int				false	"1-based page number"				minimum(1)	default(1)
+// @Param			per_page	query		int				false	"Page size, all users when omitted"	minimum(1)	maximum(100)
+// @Success		200			{array}		models.UserV2	"Successfully retrieved list of users"
+// @Failure		400			{object}	problem.Problem	"Invalid fields or paging parameters"
+// @Failure		406			{object}	problem.Problem	"No acceptable representation"
+// @Failure		500			{object}	problem.Problem	"Internal Server Error"
+// @Failure		503			{object}	problem.Problem	"User store unavailable"
+// @Failure		504			{object}	problem.Problem	"Request timed out"
+// @Router			/v2/users [get]
+func (h *UserHandlerV2) GetUsers(c *gin.Context) {
+	h.handler.GetUsers(c)
+}
+
+// CreateUser handles HTTP POST requests to the /v2/users endpoint, creating a user
+// from a CreateUserRequestV2. See UserHandler.CreateUser.
+// @Summary		Create a new user
+// @Description	add a new user to the store based on JSON payload
+// @Tags			users-v2
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			user			body		handlers.CreateUserRequestV2	true	"User data to create"
+// @Param			Idempotency-Key	header		string							false	"Client generated key making retries safe"
+// @Success		201				{object}	models.UserV2					"Successfully created user"
+// @Failure		400				{object}	problem.Problem					"Validation Error or Invalid Request Format"
+// @Failure		406				{object}	problem.Problem					"No acceptable representation"
+// @Failure		409				{object}	problem.Problem					"Email address already in use, or a request with the same Idempotency-Key is in progress"
+// @Failure		413				{object}	problem.Problem					"Request body too large"
+// @Failure		415				{object}	problem.Problem					"Unsupported Content-Type"
+// @Failure		422				{object}	problem.Problem					"Idempotency-Key reused with a different body"
+// @Failure		500				{object}	problem.Problem					"Internal Server Error"
+// @Failure		503				{object}	problem.Problem					"User store unavailable"
+// @Failure		504				{object}	problem.Problem					"Request timed out"
+// @Router			/v2/users [post]
+func (h *UserHandlerV2) CreateUser(c *gin.Context) {
+	h.handler.CreateUser(c)
+}
+
+// GetMe handles HTTP GET requests to the /v2/users/me endpoint. See UserHandler.GetMe.
+// @Summary		Get the caller's own user
+// @Description	get the user record matching the authenticated caller's email
+// @Tags			users-v2
+// @Accept			json
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			fields	query		string			false	"Comma separated fields to return, e.g. id,name.first,notifications.sms"
+// @Success		200		{object}	models.UserV2	"Successfully retrieved user"
+// @Failure		400		{object}	problem.Problem	"Invalid fields parameter"
+// @Failure		401		{object}	problem.Problem	"No authenticated identity"
+// @Failure		404		{object}	problem.Problem	"No user for the authenticated identity"
+// @Failure		406		{object}	problem.Problem	"No acceptable representation"
+// @Failure		500		{object}	problem.Problem	"Internal Server Error"
+// @Failure		503		{object}	problem.Problem	"User store unavailable"
+// @Failure		504		{object}	problem.Problem	"Request timed out"
+// @Router			/v2/users/me [get]
+func (h *UserHandlerV2) GetMe(c *gin.Context) {
+	h.handler.GetMe(c)
+}
+
+// UpdateMe handles HTTP PUT requests to the /v2/users/me endpoint, replacing the
+// caller's profile with an UpdateMeRequestV2. See UserHandler.UpdateMe.
+// @Summary		Replace the caller's own profile
+// @Description	update profile fields and preferences of the authenticated caller (PUT semantics)
+// @Tags			users-v2
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			user	body		handlers.UpdateMeRequestV2	true	"Profile data to update"
+// @Success		200		{object}	models.UserV2				"Successfully updated user"
+// @Failure		400		{object}	problem.Problem				"Validation Error or Invalid Request Format"
+// @Failure		406		{object}	problem.Problem				"No acceptable representation"
+// @Failure		413		{object}	problem.Problem				"Request body too large"
+// @Failure		415		{object}	problem.Problem				"Unsupported Content-Type"
+// @Failure		401		{object}	problem.Problem				"No authenticated identity"
+// @Failure		404		{object}	problem.Problem				"No user for the authenticated identity"
+// @Failure		500		{object}	problem.Problem				"Internal Server Error"
+// @Failure		503		{object}	problem.Problem				"User store unavailable"
+// @Failure		504		{object}	problem.Problem				"Request timed out"
+// @Router			/v2/users/me [put]
+func (h *UserHandlerV2) UpdateMe(c *gin.Context) {
+	h.handler.UpdateMe(c)
+}
+
+// PatchMe handles HTTP PATCH requests to the /v2/users/me endpoint, applying a
+// PatchMeRequestV2 to the caller's profile. See UserHandler.PatchMe.
+// @Summary		Partially update the caller's own profile
+// @Description	update selected profile fields and preferences of the authenticated caller (PATCH semantics)
+// @Tags			users-v2
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			user	body		handlers.PatchMeRequestV2	true	"Profile fields to update"
+// @Success		200		{object}	models.UserV2				"Successfully updated user"
+// @Failure		400		{object}	problem.Problem				"Validation Error or Invalid Request Format"
+// @Failure		406		{object}	problem.Problem				"No acceptable representation"
+// @Failure		413		{object}	problem.Problem				"Request body too large"
+// @Failure		415		{object}	problem.Problem				"Unsupported Content-Type"
+// @Failure		401		{object}	problem.Problem				"No authenticated identity"
+// @Failure		404		{object}	problem.Problem				"No user for the authenticated identity"
+// @Failure		500		{object}	problem.Problem				"Internal Server Error"
+// @Failure		503		{object}	problem.Problem				"User store unavailable"
+// @Failure		504		{object}	problem.Problem				"Request timed out"
+// @Router			/v2/users/me [patch]
+func (h *UserHandlerV2) PatchMe(c *gin.Context) {
+	h.handler.PatchMe(c)
+}
+
+// GetUserByID handles HTTP GET requests to the /v2/users/:id endpoint. See UserHandler.GetUserByID.
+// @Summary		Get a single user by ID
+// @Description	get user by ID string from path parameter
+// @Tags			users-v2
+// @Accept			json
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			id		path		string			true	"User ID (UUID)"	Format(uuid)
+// @Param			fields	query		string			false	"Comma separated fields to return, e.g. id,name.first,notifications.sms"
+// @Success		200		{object}	models.UserV2	"Successfully retrieved user"
+// @Failure		400		{object}	problem.Problem	"Invalid fields parameter"
+// @Failure		404		{object}	problem.Problem	"User not found"
+// @Failure		406		{object}	problem.Problem	"No acceptable representation"
+// @Failure		500		{object}	problem.Problem	"Internal Server Error"
+// @Failure		503		{object}	problem.Problem	"User store unavailable"
+// @Failure		504		{object}	problem.Problem	"Request timed out"
+// @Router			/v2/users/{id} [get]
+func (h *UserHandlerV2) GetUserByID(c *gin.Context) {
+	h.handler.GetUserByID(c)
+}
+
+// UpdateUser handles HTTP PUT requests to the /v2/users/:id endpoint, replacing the
+// user with an UpdateUserRequestV2. See UserHandler.UpdateUser.
+// @Summary		Update an existing user
+// @Description	update user data for the given ID based on JSON payload (PUT semantics)
+// @Tags			users-v2
+// @Accept			json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			id		path		string							true	"User ID (UUID)"	Format(uuid)
+// @Param			user	body		handlers.UpdateUserRequestV2	true	"User data to update"
+// @Success		200		{object}	models.UserV2					"Successfully updated user"
+// @Failure		400		{object}	problem.Problem					"Validation Error or Invalid Request Format"
+// @Failure		406		{object}	problem.Problem					"No acceptable representation"
+// @Failure		409		{object}	problem.Problem					"Email address already in use"
+// @Failure		413		{object}	problem.Problem					"Request body too large"
+// @Failure		415		{object}	problem.Problem					"Unsupported Content-Type"
+// @Failure		404		{object}	problem.Problem					"User not found"
+// @Failure		500		{object}	problem.Problem					"Internal Server Error"
+// @Failure		503		{object}	problem.Problem					"User store unavailable"
+// @Failure		504		{object}	problem.Problem					"Request timed out"
+// @Router			/v2/users/{id} [put]
+func (h *UserHandlerV2) UpdateUser(c *gin.Context) {
+	h.handler.UpdateUser(c)
+}
+
+// DeleteUser handles HTTP DELETE requests to the /v2/users/:id endpoint. See UserHandler.DeleteUser.
+// @Summary		Delete a user by ID
+// @Description	remove user from the store by ID string from path parameter
+// @Tags			users-v2
+// @Accept			json
+// @Produce		json,application/xml,application/yaml,application/msgpack,application/cbor
+// @Param			id	path		string			true	"User ID (UUID)"	Format(uuid)
+// @Success		204	{object}	nil				"Successfully deleted user (No Content)"
+// @Failure		404	{object}	problem.Problem	"User not found"
+// @Failure		406	{object}	problem.Problem	"No acceptable representation"
+// @Failure		500	{object}	problem.Problem	"Internal Server Error"
+// @Failure		503	{object}	problem.Problem	"User store unavailable"
+// @Failure		504	{object}	problem.Problem	"Request timed out"
+// @Router			/v2/users/{id} [delete]
+func (h *UserHandlerV2) DeleteUser(c *gin.Context) {
+	h.handler.DeleteUser(c)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"reflect"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+)
+
+// userInput is a request body describing changes to a user. Each API version has
+// its own request types, so that validation errors name the fields the client
+// sent; they all implement userInput to be applied to the stored models.User.
+type userInput interface {
+	// apply copies the fields of the request onto user.
+	apply(user *models.User)
+}
+
+// userVersion describes how one version of the API represents users: the
+// resource it returns and the request bodies it accepts.
+type userVersion struct {
+	// resource is the type users are returned as, used to validate sparse fieldsets.
+	resource reflect.Type
+	// render converts a stored user to the version's resource.
+	render func(user models.User) any
+	// renderList converts stored users to a slice of the version's resource.
+	renderList func(users []models.User) any
+
+	// newCreateRequest returns an empty body of POST /users.
+	newCreateRequest func() userInput
+	// newUpdateRequest returns an empty body of PUT /users/:id.
+	newUpdateRequest func() userInput
+	// newUpdateMeRequest returns an empty body of PUT /users/me.
+	newUpdateMeRequest func() userInput
+	// newPatchMeRequest returns an empty body of PATCH /users/me.
+	newPatchMeRequest func() userInput
+}
+
+// newUserVersion returns a userVersion representing users as R, converted by render.
+func newUserVersion[R any](render func(models.User) R) userVersion {
+	return userVersion{
+		resource: reflect.TypeFor[R](),
+		render: func(user models.User) any {
+			return render(user)
+		},
+		renderList: func(users []models.User) any {
+			resources := make([]R, len(users))
+			for i, user := range users {
+				resources[i] = render(user)
+			}
+
+			return resources
+		},
+	}
+}
+
+// userV1 represents users as models.UserV1, the shape of the original, unversioned API.
+var userV1 = func() userVersion {
+	v := newUserVersion(models.NewUserV1)
+	v.newCreateRequest = func() userInput { return &CreateUserRequest{} }
+	v.newUpdateRequest = func() userInput { return &UpdateUserRequest{} }
+	v.newUpdateMeRequest = func() userInput { return &UpdateMeRequest{} }
+	v.newPatchMeRequest = func() userInput { return &PatchMeRequest{} }
+
+	return v
+}()
+
+// userV2 represents users as models.UserV2.
+var userV2 = func() userVersion {
+	v := newUserVersion(models.NewUserV2)
+	v.newCreateRequest = func() userInput { return &CreateUserRequestV2{} }
+	v.newUpdateRequest = func() userInput { return &UpdateUserRequestV2{} }
+	v.newUpdateMeRequest = func() userInput { return &UpdateMeRequestV2{} }
+	v.newPatchMeRequest = func() userInput { return &PatchMeRequestV2{} }
+
+	return v
+}()
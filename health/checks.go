@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Pinger is implemented by dependencies that can verify their own connectivity,
+// such as backend stores. Its Ping method can be registered directly as a CheckFunc.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// DiskWritable returns a CheckFunc verifying that a file can be created, written
+// and removed in dir. An empty dir checks the system temporary directory.
+func DiskWritable(dir string) CheckFunc {
+	return func(context.Context) error {
+		file, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return fmt.Errorf("create probe file: %w", err)
+		}
+		name := file.Name()
+		defer os.Remove(name) //nolint:errcheck // Best effort cleanup, a failed write is what we report
+
+		if _, err := file.WriteString("ok"); err != nil {
+			_ = file.Close()
+
+			return fmt.Errorf("write probe file: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("close probe file: %w", err)
+		}
+
+		return nil
+	}
+}
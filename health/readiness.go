@@ -0,0 +1,30 @@
+// Package health tracks whether the service is able and willing to receive traffic.
+package health
+
+import (
+	"sync/atomic"
+)
+
+// Readiness tracks whether the service should receive new traffic. It starts out
+// ready and is flipped to draining once shutdown begins, so that health probes
+// fail and load balancers stop routing new requests while in-flight ones finish.
+//
+// The zero value is ready to use and safe for concurrent use.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// NewReadiness creates a Readiness in the ready state.
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// StartDraining marks the service as shutting down. It cannot be undone.
+func (r *Readiness) StartDraining() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether StartDraining has been called.
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
@@ -0,0 +1,225 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status is the outcome of a health check or of a whole report.
+type Status string
+
+const (
+	// StatusUp means the check passed.
+	StatusUp Status = "UP"
+	// StatusDegraded means the check found a problem that impairs, but does not prevent,
+	// serving traffic. A degraded report still passes the readiness probe.
+	StatusDegraded Status = "DEGRADED"
+	// StatusDown means the check failed and the service should not receive traffic.
+	StatusDown Status = "DOWN"
+)
+
+// ErrDraining is reported by the readiness report once shutdown has begun.
+var ErrDraining = errors.New("service is shutting down")
+
+// CheckFunc verifies a single dependency. It returns nil when the dependency is
+// healthy, an error wrapped with Degraded when it is impaired but the service can
+// still serve traffic, and any other error when it is down. It should give up when
+// ctx is done.
+type CheckFunc func(ctx context.Context) error
+
+// degradedError marks a check failure as degraded rather than down.
+type degradedError struct {
+	err error
+}
+
+// Error implements the error interface.
+func (e *degradedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *degradedError) Unwrap() error {
+	return e.err
+}
+
+// Degraded wraps err to report the check as DEGRADED instead of DOWN.
+func Degraded(err error) error {
+	return &degradedError{err: err}
+}
+
+// CheckResult is the outcome of a single named check.
+type CheckResult struct {
+	// Status is UP when the check passed, DEGRADED when it returned a Degraded error
+	// and DOWN otherwise.
+	Status Status `json:"status"`
+	// Error describes why the check failed. Empty when it passed.
+	Error string `json:"error,omitempty"`
+	// Duration is how long the check took to run.
+	Duration string `json:"duration"`
+}
+
+// Report aggregates the results of every registered check.
+type Report struct {
+	// Status is DOWN when any check is down, DEGRADED when any check is degraded
+	// and UP otherwise.
+	Status Status `json:"status"`
+	// Checks holds the result of each check by name.
+	Checks map[string]CheckResult `json:"checks"`
+	// CheckedAt is when the checks ran. Reports are cached, so it may lag behind the request.
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// check is a registered CheckFunc with its name and timeout.
+type check struct {
+	name    string
+	timeout time.Duration
+	run     CheckFunc
+}
+
+// Registry holds the named dependency checks that decide whether the service is ready.
+//
+// Reports are cached for the configured TTL and concurrent callers share a single
+// evaluation, so frequent probes (or many instances of a probe) cannot stampede
+// the dependencies. Checks run in parallel, each bounded by its own timeout.
+//
+// A Registry is safe for concurrent use.
+type Registry struct {
+	readiness *Readiness
+	cacheTTL  time.Duration
+
+	mu     sync.Mutex
+	checks []check
+	cached *Report
+}
+
+// NewRegistry creates an empty Registry.
+//
+// Parameters:
+//   - readiness: The shutdown state. Once draining, reports are DOWN regardless of the checks.
+//   - cacheTTL: How long a report is reused before the checks run again.
+func NewRegistry(readiness *Readiness, cacheTTL time.Duration) *Registry {
+	return &Registry{
+		readiness: readiness,
+		cacheTTL:  cacheTTL,
+	}
+}
+
+// Readiness returns the shutdown state the registry reports on.
+func (r *Registry) Readiness() *Readiness {
+	return r.readiness
+}
+
+// Register adds a named check. Each run of fn is cancelled after timeout.
+// Registering a check invalidates the cached report.
+func (r *Registry) Register(name string, timeout time.Duration, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check{name: name, timeout: timeout, run: fn})
+	r.cached = nil
+}
+
+// Check returns the current readiness report, running the checks if the cached
+// report has expired. While the service is draining it returns a DOWN report
+// immediately, without running or caching the checks.
+//
+// The checks run detached from the caller's context, so a probe that disconnects
+// early cannot poison the cached report for everyone else.
+func (r *Registry) Check() Report {
+	if r.readiness.Draining() {
+		return Report{
+			Status: StatusDown,
+			Checks: map[string]CheckResult{
+				"shutdown": {Status: StatusDown, Error: ErrDraining.Error(), Duration: "0s"},
+			},
+			CheckedAt: time.Now(),
+		}
+	}
+
+	// Holding the lock while the checks run makes concurrent callers wait for,
+	// and then share, a single evaluation
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cached != nil && time.Since(r.cached.CheckedAt) < r.cacheTTL {
+		return *r.cached
+	}
+
+	report := r.run()
+	r.cached = &report
+
+	return report
+}
+
+// run executes every check in parallel and aggregates the results.
+// The caller must hold r.mu.
+func (r *Registry) run() Report {
+	report := Report{
+		Status:    StatusUp,
+		Checks:    make(map[string]CheckResult, len(r.checks)),
+		CheckedAt: time.Now(),
+	}
+
+	results := make([]CheckResult, len(r.checks))
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(c)
+		}()
+	}
+	wg.Wait()
+
+	for i, c := range r.checks {
+		report.Checks[c.name] = results[i]
+		switch {
+		case results[i].Status == StatusDown:
+			report.Status = StatusDown
+		case results[i].Status == StatusDegraded && report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+// runCheck runs a single check within its timeout. A check that ignores its
+// context is abandoned (and reported as timed out) once the timeout expires.
+func runCheck(c check) CheckResult {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- c.run(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s: %w", c.timeout, ctx.Err())
+	}
+
+	result := CheckResult{Status: StatusUp, Duration: time.Since(start).Round(time.Microsecond).String()}
+	var degraded *degradedError
+	switch {
+	case errors.As(err, &degraded):
+		result.Status = StatusDegraded
+		result.Error = err.Error()
+	case err != nil:
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,143 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistryCheck(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	degraded := func(context.Context) error { return Degraded(errors.New("one of four connections")) }
+	hanging := func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }
+	stuck := func(context.Context) error { select {} }
+	panicking := func(context.Context) error { panic("boom") }
+
+	tests := []struct {
+		name       string
+		checks     map[string]CheckFunc
+		wantStatus Status
+		wantChecks map[string]Status
+	}{
+		{name: "no checks", wantStatus: StatusUp, wantChecks: map[string]Status{}},
+		{
+			name:       "all up",
+			checks:     map[string]CheckFunc{"a": up, "b": up},
+			wantStatus: StatusUp,
+			wantChecks: map[string]Status{"a": StatusUp, "b": StatusUp},
+		},
+		{
+			name:       "degraded",
+			checks:     map[string]CheckFunc{"a": up, "b": degraded},
+			wantStatus: StatusDegraded,
+			wantChecks: map[string]Status{"a": StatusUp, "b": StatusDegraded},
+		},
+		{
+			name:       "down wins over degraded",
+			checks:     map[string]CheckFunc{"a": down, "b": degraded},
+			wantStatus: StatusDown,
+			wantChecks: map[string]Status{"a": StatusDown, "b": StatusDegraded},
+		},
+		{
+			name:       "timeouts and panics are down",
+			checks:     map[string]CheckFunc{"hanging": hanging, "stuck": stuck, "panicking": panicking},
+			wantStatus: StatusDown,
+			wantChecks: map[string]Status{"hanging": StatusDown, "stuck": StatusDown, "panicking": StatusDown},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(NewReadiness(), 0)
+			for name, fn := range tt.checks {
+				registry.Register(name, 50*time.Millisecond, fn)
+			}
+
+			report := registry.Check()
+			if report.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if len(report.Checks) != len(tt.wantChecks) {
+				t.Errorf("Checks = %v, want %v", report.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				result := report.Checks[name]
+				if result.Status != want {
+					t.Errorf("Checks[%q].Status = %s, want %s", name, result.Status, want)
+				}
+				if (result.Error != "") != (want != StatusUp) {
+					t.Errorf("Checks[%q].Error = %q with status %s", name, result.Error, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryCachesReports(t *testing.T) {
+	var runs atomic.Int32
+	registry := NewRegistry(NewReadiness(), time.Hour)
+	registry.Register("slow", time.Second, func(context.Context) error {
+		runs.Add(1)
+		time.Sleep(20 * time.Millisecond)
+
+		return nil
+	})
+
+	// Concurrent probes share a single evaluation
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			registry.Check()
+		}()
+	}
+	wg.Wait()
+	registry.Check()
+	if got := runs.Load(); got != 1 {
+		t.Errorf("check ran %d times, want 1", got)
+	}
+
+	// Registering a check invalidates the cached report
+	registry.Register("other", time.Second, func(context.Context) error { return nil })
+	registry.Check()
+	if got := runs.Load(); got != 2 {
+		t.Errorf("check ran %d times after Register, want 2", got)
+	}
+}
+
+func TestRegistryDraining(t *testing.T) {
+	readiness := NewReadiness()
+	registry := NewRegistry(readiness, time.Hour)
+	var runs atomic.Int32
+	registry.Register("up", time.Second, func(context.Context) error {
+		runs.Add(1)
+
+		return nil
+	})
+	if report := registry.Check(); report.Status != StatusUp {
+		t.Fatalf("Status = %s before draining, want UP", report.Status)
+	}
+
+	readiness.StartDraining()
+	report := registry.Check()
+	if report.Status != StatusDown || report.Checks["shutdown"].Error != ErrDraining.Error() {
+		t.Errorf("report while draining = %+v, want DOWN with the shutdown check", report)
+	}
+	if runs.Load() != 1 {
+		t.Error("checks ran while draining")
+	}
+}
+
+func TestDiskWritable(t *testing.T) {
+	if err := DiskWritable(t.TempDir())(context.Background()); err != nil {
+		t.Errorf("DiskWritable() on a temporary directory: %v", err)
+	}
+	if err := DiskWritable(filepath.Join(t.TempDir(), "missing"))(context.Background()); err == nil {
+		t.Error("DiskWritable() on a missing directory succeeded, want an error")
+	}
+}
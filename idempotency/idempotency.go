@@ -0,0 +1,42 @@
+// Package idempotency stores the outcome of requests made with an Idempotency-Key,
+// so a retried request can be answered with the original response instead of
+// being executed twice.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Response is a stored HTTP response, replayed for retries of the original request.
+type Response struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Header holds the response headers worth replaying, such as Content-Type and Location.
+	Header http.Header
+	// Body is the response body.
+	Body []byte
+}
+
+// Record is the state stored for an idempotency key.
+type Record struct {
+	// Fingerprint identifies the request first made with the key, e.g. a hash of its body.
+	// A retry with a different fingerprint is a client error.
+	Fingerprint string
+	// Response is the response to replay, or nil while the first request is still in progress.
+	Response *Response
+}
+
+// Store persists idempotency records. Implementations must be safe for concurrent use.
+type Store interface {
+	// Reserve claims key for a new request identified by fingerprint, recording it as
+	// in progress for ttl. If the key is already known, nothing is changed and the
+	// existing record is returned instead; a nil record means the key was claimed.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Complete stores response for a key claimed with Reserve, keeping it for ttl.
+	Complete(ctx context.Context, key string, response Response, ttl time.Duration) error
+	// Release forgets a key claimed with Reserve whose request should not be replayed,
+	// e.g. because it failed with a server error, so it can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,98 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval controls how often expired records are evicted from a MemoryStore.
+const sweepInterval = time.Minute
+
+// entry is a record stored by a MemoryStore with its expiry.
+type entry struct {
+	record  Record
+	expires time.Time
+}
+
+// MemoryStore is a Store that keeps records in process memory. Keys are only
+// known to the instance that served the first request, so it is best suited
+// to single instance deployments and local development.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory idempotency store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]*entry),
+		now:     time.Now,
+	}
+}
+
+// Reserve implements Store.
+//
+// This function is safe for concurrent use.
+func (s *MemoryStore) Reserve(_ context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expires) {
+		record := e.record
+
+		return &record, nil
+	}
+	s.entries[key] = &entry{record: Record{Fingerprint: fingerprint}, expires: now.Add(ttl)}
+
+	return nil, nil
+}
+
+// Complete implements Store.
+//
+// This function is safe for concurrent use.
+func (s *MemoryStore) Complete(_ context.Context, key string, response Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		// The reservation expired and was swept while the request was served
+		return nil
+	}
+	e.record.Response = &response
+	e.expires = s.now().Add(ttl)
+
+	return nil
+}
+
+// Release implements Store.
+//
+// This function is safe for concurrent use.
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+// sweep drops expired records, bounding memory usage by the number of keys used
+// within the TTL. It runs at most once per sweepInterval. The caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
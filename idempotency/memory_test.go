@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	if record, err := store.Reserve(ctx, "k", "a", time.Minute); err != nil || record != nil {
+		t.Fatalf("first Reserve() = %+v, %v, want the key claimed", record, err)
+	}
+	record, err := store.Reserve(ctx, "k", "b", time.Minute)
+	if err != nil || record == nil || record.Fingerprint != "a" || record.Response != nil {
+		t.Fatalf("Reserve() while in progress = %+v, %v, want the in-progress record of a", record, err)
+	}
+
+	response := Response{StatusCode: http.StatusCreated, Header: http.Header{"Location": {"/users/1"}}, Body: []byte("{}")}
+	if err := store.Complete(ctx, "k", response, time.Hour); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	now = start.Add(30 * time.Minute)
+	record, err = store.Reserve(ctx, "k", "a", time.Minute)
+	if err != nil || record == nil || record.Response == nil || record.Response.StatusCode != http.StatusCreated {
+		t.Fatalf("Reserve() after Complete = %+v, %v, want the stored response", record, err)
+	}
+
+	// The completed record lives for the TTL passed to Complete
+	now = start.Add(time.Hour + time.Second)
+	if record, _ := store.Reserve(ctx, "k", "c", time.Minute); record != nil {
+		t.Errorf("Reserve() after expiry = %+v, want the key claimed again", record)
+	}
+
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if record, _ := store.Reserve(ctx, "k", "d", time.Minute); record != nil {
+		t.Errorf("Reserve() after Release = %+v, want the key claimed again", record)
+	}
+}
+
+func TestMemoryStoreSweepsExpiredRecords(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	for _, key := range []string{"a", "b"} {
+		_, _ = store.Reserve(ctx, key, "", time.Second)
+	}
+	now = start.Add(sweepInterval + time.Second)
+	_, _ = store.Reserve(ctx, "c", "", time.Second)
+	if len(store.entries) != 1 {
+		t.Errorf("%d records stored, want only the one of c", len(store.entries))
+	}
+
+	// Completing a swept reservation is a no-op
+	if err := store.Complete(ctx, "a", Response{}, time.Minute); err != nil || len(store.entries) != 1 {
+		t.Errorf("Complete() of a swept key: %v, %d records", err, len(store.entries))
+	}
+}
@@ -1,18 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
 	"github.com/thoughtgears/cloudflare-tunnels-poc/config"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/crashreport"
 	_ "github.com/thoughtgears/cloudflare-tunnels-poc/docs"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/geocode"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/ratelimit"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/router"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/router/middleware"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/tracing"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/tunnel"
 )
 
 // cfg holds the application's configuration, loaded from environment variables
@@ -21,7 +37,8 @@ var cfg config.Config
 
 // init performs initial setup before the main function runs.
 // It loads configuration from environment variables into the global cfg variable
-// using envconfig and initializes the global zerolog logger settings.
+// using envconfig and initializes the global zerolog logger settings, including
+// the output format selected by cfg.LogFormat.
 func init() {
 	envconfig.MustProcess("", &cfg)
 
@@ -30,13 +47,36 @@ func init() {
 
 	if cfg.Debug {
 		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = string(middleware.LogFormatJSON)
+		if cfg.Debug {
+			cfg.LogFormat = "console"
+		}
+	}
+
+	switch cfg.LogFormat {
+	case "console":
 		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	case string(middleware.LogFormatGCP):
+		// Cloud Logging expects its own severity names and parses RFC 3339 timestamps with nanoseconds
+		zerolog.LevelFieldMarshalFunc = middleware.CloudSeverity
+		zerolog.TimeFieldFormat = time.RFC3339Nano
+	case string(middleware.LogFormatJSON):
+	default:
+		log.Fatal().Msgf("Unknown log format %q", cfg.LogFormat)
 	}
+
+	// Fall back to the global logger when no request-scoped logger is in the context
+	zerolog.DefaultContextLogger = &log.Logger
 }
 
 // @title			User Service
 // @version		1.0
 // @description	This is a sample server for managing users.
+// @description	Version 1 of the users API is served under /v1 and under the original unversioned /users paths; version 2 is served under /v2.
+// @description	The version is selected by the path only: media type parameters such as Accept: application/vnd.example+json; version=2 are not supported.
 // @termsOfService	http://swagger.io/terms/
 
 // @contact.name	API Support
@@ -50,15 +90,65 @@ func init() {
 // @BasePath	/
 // @schemes	https
 func main() {
+	// --- Tracing Setup ---
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		FilePath:    cfg.TracingFile,
+		ServiceName: cfg.ServiceName,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+
 	// --- Dependency Initialization ---
-	userService := services.NewUserService()
+	userService := services.NewUserService(newGeocoder(cfg), cfg.PhoneDefaultRegion)
+	rateLimitStore := newRateLimitStore(cfg)
+	readiness := health.NewReadiness()
+
+	// --- Health Checks ---
+	healthRegistry := health.NewRegistry(readiness, cfg.HealthCacheTTL)
+	healthRegistry.Register("user_store", cfg.HealthCheckTimeout, userService.Ping)
+	healthRegistry.Register("disk_writable", cfg.HealthCheckTimeout, health.DiskWritable(cfg.HealthDiskPath))
+	if pinger, ok := rateLimitStore.(health.Pinger); ok {
+		healthRegistry.Register("rate_limit_store", cfg.HealthCheckTimeout, pinger.Ping)
+	}
+	if cfg.TunnelMetricsURL != "" {
+		healthRegistry.Register("tunnel", cfg.HealthCheckTimeout,
+			tunnel.NewMetricsCheck(http.DefaultClient, cfg.TunnelMetricsURL, cfg.TunnelMinConnections))
+	}
+
+	// --- Crash Reporting ---
+	var crashReports crashreport.Sink
+	var crashWebhook *crashreport.Webhook
+	if cfg.CrashReportWebhookURL != "" {
+		crashWebhook = crashreport.NewWebhook(cfg.CrashReportWebhookURL, cfg.ServiceName, &http.Client{Timeout: 5 * time.Second})
+		crashReports = crashWebhook
+	}
 
 	// --- Router Setup ---
-	routerEngine := router.NewRouter(cfg, userService)
+	routerEngine := router.NewRouter(cfg, userService, rateLimitStore, healthRegistry, crashReports)
 
 	// --- Init swagger Paths ---
 	routerEngine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// --- Shutdown Hooks ---
+	// Run in order once the main server has drained
+	hooks := []shutdownHook{{name: "tracing", run: shutdownTracing}}
+	if closer, ok := rateLimitStore.(io.Closer); ok {
+		hooks = append(hooks, shutdownHook{name: "rate limit store", run: func(context.Context) error { return closer.Close() }})
+	}
+	if crashWebhook != nil {
+		hooks = append(hooks, shutdownHook{name: "crash reports", run: crashWebhook.Shutdown})
+	}
+
+	// --- Admin Listener ---
+	// Serve metrics on a separate port when configured, keeping them off the public router
+	if cfg.MetricsPort != "" {
+		metricsServer := serveMetrics(cfg.MetricsPort)
+		hooks = append([]shutdownHook{{name: "metrics listener", run: metricsServer.Shutdown}}, hooks...)
+	}
+
 	// --- Server Configuration ---
 	// Listen on all interfaces in production/default, only localhost in debug
 	host := "0.0.0.0"
@@ -66,9 +156,144 @@ func main() {
 		host = "127.0.0.1"
 	}
 
+	server := &http.Server{
+		Addr:              host + ":" + cfg.Port,
+		Handler:           routerEngine,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    int(cfg.MaxHeaderBytes),
+	}
+
 	// --- Start Server ---
-	log.Info().Msgf("Starting server, listening on %s:%s", host, cfg.Port)
-	// routerEngine.Run blocks until the server is stopped or fails.
-	// log.Fatal will print the error and exit the application if Run returns an error.
-	log.Fatal().Err(routerEngine.Run(host + ":" + cfg.Port))
+	// SIGTERM is sent by Cloud Run (and docker stop) before the instance is killed
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info().Msgf("Starting server, listening on %s", server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		// The listener failed before any shutdown was requested (e.g. port in use)
+		runShutdownHooks(hooks, cfg.ShutdownTimeout)
+		log.Fatal().Err(err).Msg("Server stopped unexpectedly")
+	case <-signalCtx.Done():
+		// Restore default signal handling, so a second signal terminates immediately
+		stopSignals()
+		log.Info().Msg("Termination signal received, shutting down")
+	}
+
+	drain(server, readiness, cfg.ShutdownDrainDelay, cfg.ShutdownTimeout)
+	runShutdownHooks(hooks, cfg.ShutdownTimeout)
+	log.Info().Msg("Server stopped")
+}
+
+// shutdownHook is a named cleanup step run after the server has drained,
+// such as flushing buffered spans or closing backend connections.
+type shutdownHook struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// drain gracefully stops the server. It first flips readiness to draining so
+// health checks fail, keeps serving for drainDelay so load balancers can react,
+// then stops accepting connections and waits for in-flight requests to complete.
+// Connections still active when timeout (measured from the start of the drain)
+// expires are closed forcibly.
+func drain(server *http.Server, readiness *health.Readiness, drainDelay, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	readiness.StartDraining()
+	if drainDelay > 0 {
+		log.Info().Msgf("Readiness set to failing, draining for %s", drainDelay)
+		select {
+		case <-time.After(drainDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Warn().Err(err).Msg("In-flight requests did not finish in time, closing remaining connections")
+		if closeErr := server.Close(); closeErr != nil {
+			log.Error().Err(closeErr).Msg("Failed to close server")
+		}
+
+		return
+	}
+	log.Info().Msg("All in-flight requests completed")
+}
+
+// runShutdownHooks runs every hook in order, each bounded by timeout.
+// Failures are logged and do not prevent the remaining hooks from running.
+func runShutdownHooks(hooks []shutdownHook, timeout time.Duration) {
+	for _, hook := range hooks {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		if err := hook.run(ctx); err != nil {
+			log.Error().Err(err).Msgf("Shutdown hook %q failed", hook.name)
+		}
+		cancel()
+	}
+}
+
+// newRateLimitStore creates the rate limiting backend selected by cfg.RateLimitBackend.
+// It returns nil when rate limiting is disabled.
+func newRateLimitStore(cfg config.Config) ratelimit.Store {
+	switch cfg.RateLimitBackend {
+	case "none":
+		return nil
+	case "redis":
+		log.Info().Msgf("Using Redis rate limit backend at %s", cfg.RateLimitRedisAddr)
+
+		return ratelimit.NewRedisStore(redis.NewClient(&redis.Options{Addr: cfg.RateLimitRedisAddr}), "ratelimit:")
+	case "memory":
+		return ratelimit.NewMemoryStore()
+	default:
+		log.Fatal().Msgf("Unknown rate limit backend %q", cfg.RateLimitBackend)
+
+		return nil
+	}
+}
+
+// newGeocoder creates the geocoder selected by cfg.Geocoder.
+// It returns nil when geocoding is disabled.
+func newGeocoder(cfg config.Config) geocode.Geocoder {
+	switch cfg.Geocoder {
+	case "none":
+		return nil
+	case "offline":
+		return geocode.NewOffline()
+	default:
+		log.Fatal().Msgf("Unknown geocoder %q", cfg.Geocoder)
+
+		return nil
+	}
+}
+
+// serveMetrics starts the admin listener serving Prometheus metrics on /metrics
+// in the background and returns its server so it can be shut down. A listener
+// failure is logged as an error without stopping the main server.
+func serveMetrics(port string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Msgf("Serving metrics on :%s/metrics", port)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("Metrics listener stopped")
+		}
+	}()
+
+	return server
 }
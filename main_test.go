@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+)
+
+// startServer serves handler on a random local port and returns the server and its URL.
+func startServer(t *testing.T, handler http.Handler) (*http.Server, string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: time.Second}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return server, "http://" + listener.Addr().String()
+}
+
+func TestDrain(t *testing.T) {
+	tests := []struct {
+		name       string
+		handlerFor time.Duration
+		timeout    time.Duration
+		wantBody   bool
+	}{
+		{name: "in-flight request completes", handlerFor: 100 * time.Millisecond, timeout: 5 * time.Second, wantBody: true},
+		{name: "request outliving the timeout is cut off", handlerFor: 5 * time.Second, timeout: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			server, url := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.handlerFor):
+					_, _ = io.WriteString(w, "done")
+				case <-r.Context().Done():
+				}
+			}))
+
+			body := make(chan string, 1)
+			go func() {
+				resp, err := http.Get(url)
+				if err != nil {
+					body <- ""
+
+					return
+				}
+				defer resp.Body.Close()
+				data, _ := io.ReadAll(resp.Body)
+				body <- string(data)
+			}()
+			<-started
+
+			readiness := health.NewReadiness()
+			start := time.Now()
+			drain(server, readiness, 0, tt.timeout)
+			if !readiness.Draining() {
+				t.Error("readiness not draining after drain")
+			}
+			if elapsed := time.Since(start); elapsed > tt.timeout+time.Second {
+				t.Errorf("drain took %v, want at most about %v", elapsed, tt.timeout)
+			}
+			if got := <-body; (got == "done") != tt.wantBody {
+				t.Errorf("response body = %q, want completed = %v", got, tt.wantBody)
+			}
+			if _, err := http.Get(url); err == nil {
+				t.Error("server still accepts connections after drain")
+			}
+		})
+	}
+}
+
+func TestDrainWaitsForDrainDelay(t *testing.T) {
+	server, url := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	readiness := health.NewReadiness()
+
+	done := make(chan struct{})
+	go func() {
+		drain(server, readiness, 200*time.Millisecond, 5*time.Second)
+		close(done)
+	}()
+
+	// New requests are still served while load balancers notice the failing readiness
+	time.Sleep(50 * time.Millisecond)
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("request during drain delay: %v", err)
+	}
+	_ = resp.Body.Close()
+	if !readiness.Draining() {
+		t.Error("readiness not draining during drain delay")
+	}
+	<-done
+}
+
+func TestRunShutdownHooks(t *testing.T) {
+	var ran []string
+	hook := func(name string, err error) shutdownHook {
+		return shutdownHook{name: name, run: func(ctx context.Context) error {
+			if _, ok := ctx.Deadline(); !ok {
+				t.Errorf("hook %q run without deadline", name)
+			}
+			ran = append(ran, name)
+
+			return err
+		}}
+	}
+
+	runShutdownHooks([]shutdownHook{
+		hook("metrics listener", nil),
+		hook("tracing", errors.New("flush failed")),
+		hook("rate limit store", nil),
+	}, time.Second)
+
+	if want := []string{"metrics listener", "tracing", "rate limit store"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("hooks ran = %v, want %v", ran, want)
+	}
+}
@@ -0,0 +1,122 @@
+// Package metrics defines the Prometheus collectors exported by the service and
+// the HTTP handler serving them.
+//
+// All collectors are registered on Registry rather than the global default
+// registry, so the exposed metric set is exactly what this package declares
+// (plus the standard Go runtime and process collectors).
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric name exported by the service.
+const namespace = "user_service"
+
+// Registry holds every collector exported by the service.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequestsTotal counts handled requests by route template, method and status code.
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests handled, by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestDuration observes request latencies by route template, method and status code.
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of HTTP requests in seconds, by route template, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	// HTTPRequestsInFlight tracks requests currently being served by route template and method.
+	HTTPRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "Number of HTTP requests currently being served, by route template and method.",
+	}, []string{"route", "method"})
+
+	// HTTPPanicsTotal counts panics recovered while serving requests, by route template and method.
+	HTTPPanicsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "panics_total",
+		Help:      "Total number of panics recovered while serving HTTP requests, by route template and method.",
+	}, []string{"route", "method"})
+
+	// StoreUsers reports the number of users currently held by the user store.
+	StoreUsers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "users",
+		Help:      "Number of users currently held by the user store.",
+	})
+
+	// TunnelUp reports whether the last scrape of the cloudflared metrics endpoint succeeded.
+	TunnelUp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "tunnel",
+		Name:      "up",
+		Help:      "Whether the last scrape of the cloudflared metrics endpoint succeeded (1) or not (0).",
+	})
+
+	// TunnelHAConnections reports the number of cloudflared connections to the Cloudflare edge.
+	TunnelHAConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "tunnel",
+		Name:      "ha_connections",
+		Help:      "Number of active cloudflared connections to the Cloudflare edge, as of the last scrape.",
+	})
+
+	// StoreOperationDuration observes user store operation latencies by operation and outcome.
+	StoreOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "operation_duration_seconds",
+		Help:      "Duration of user store operations in seconds, by operation and outcome.",
+		Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1},
+	}, []string{"operation", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		HTTPPanicsTotal,
+		StoreUsers,
+		StoreOperationDuration,
+		TunnelUp,
+		TunnelHAConnections,
+	)
+}
+
+// ObserveStoreOperation records the duration of a user store operation started at
+// start, labelled "error" if err is non-nil and "success" otherwise.
+// It is meant to be deferred at the top of a store operation.
+func ObserveStoreOperation(operation string, start time.Time, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	StoreOperationDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+// Handler returns an http.Handler serving every collector in Registry in the
+// Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
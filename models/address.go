@@ -0,0 +1,206 @@
+package models
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// Coordinates is a position on the earth in decimal degrees (WGS 84).
+type Coordinates struct {
+	// Latitude is the north-south position, from -90 to 90.
+	Latitude float64 `json:"latitude" xml:"latitude"`
+	// Longitude is the east-west position, from -180 to 180.
+	Longitude float64 `json:"longitude" xml:"longitude"`
+}
+
+// Address is a postal address.
+type Address struct {
+	// Lines holds the street address, e.g. street, house number and apartment, one entry per line.
+	Lines []string `json:"lines" xml:"lines>line"`
+	// City is the city, town or locality.
+	City string `json:"city" xml:"city"`
+	// Region is the state, province or county, where the country uses one.
+	Region string `json:"region" xml:"region"`
+	// PostalCode is the postal code, in the format of Country.
+	PostalCode string `json:"postal_code" xml:"postal_code"`
+	// Country is the ISO 3166-1 alpha-2 code of the country, e.g. "NO".
+	Country string `json:"country" xml:"country"`
+	// Coordinates is where the address is located, as resolved by a geocoder. It is
+	// set by the service and nil if the address could not be geocoded.
+	Coordinates *Coordinates `json:"coordinates,omitempty" xml:"coordinates,omitempty"`
+}
+
+// IsZero reports whether a holds no address at all.
+func (a Address) IsZero() bool {
+	return len(a.Lines) == 0 && a.City == "" && a.Region == "" && a.PostalCode == "" && a.Country == ""
+}
+
+// SameLocation reports whether a and other describe the same address, ignoring Coordinates.
+func (a Address) SameLocation(other Address) bool {
+	return slices.Equal(a.Lines, other.Lines) && a.City == other.City && a.Region == other.Region &&
+		a.PostalCode == other.PostalCode && a.Country == other.Country
+}
+
+// String formats a as a single line, e.g. "1 Main St, Springfield, IL 62701, US",
+// the form ParseAddress reads back.
+func (a Address) String() string {
+	parts := slices.Clone(a.Lines)
+	if a.City != "" {
+		parts = append(parts, a.City)
+	}
+	if area := strings.TrimSpace(a.Region + " " + a.PostalCode); area != "" {
+		parts = append(parts, area)
+	}
+	if a.Country != "" {
+		parts = append(parts, a.Country)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// UnmarshalJSON decodes an Address object, or an address written as a single
+// string, as stored before addresses were structured, which is parsed with ParseAddress.
+// The representation formats other than XML decode through JSON, so this covers them as well.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var legacy string
+	if err := json.Unmarshal(data, &legacy); err == nil {
+		*a = ParseAddress(legacy)
+
+		return nil
+	}
+
+	// address has the fields of Address without its methods, avoiding recursion
+	type address Address
+	*a = Address{}
+
+	return json.Unmarshal(data, (*address)(a))
+}
+
+// UnmarshalXML decodes an <address> element with an element per field, or an
+// address written as the element's text, as stored before addresses were
+// structured, which is parsed with ParseAddress.
+func (a *Address) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	// address has the fields of Address without its methods, avoiding recursion
+	type address Address
+	var element struct {
+		address
+		Text string `xml:",chardata"`
+	}
+	if err := d.DecodeElement(&element, &start); err != nil {
+		return err
+	}
+
+	*a = Address(element.address)
+	if text := strings.TrimSpace(element.Text); text != "" && a.IsZero() {
+		*a = ParseAddress(text)
+	}
+
+	return nil
+}
+
+// countryNames maps lower-cased country names found in free-text addresses to
+// ISO 3166-1 alpha-2 codes.
+var countryNames = map[string]string{
+	"united states": "US", "united states of america": "US", "usa": "US",
+	"united kingdom": "GB", "uk": "GB", "great britain": "GB", "england": "GB", "scotland": "GB", "wales": "GB",
+	"canada": "CA", "australia": "AU", "new zealand": "NZ", "ireland": "IE",
+	"germany": "DE", "deutschland": "DE", "france": "FR", "netherlands": "NL", "belgium": "BE",
+	"spain": "ES", "italy": "IT", "portugal": "PT", "switzerland": "CH", "austria": "AT",
+	"norway": "NO", "norge": "NO", "sweden": "SE", "sverige": "SE", "denmark": "DK", "finland": "FI",
+	"poland": "PL", "japan": "JP", "india": "IN", "brazil": "BR",
+}
+
+// postalCodeToken matches the postal code in the last line of a free-text address:
+// a UK style code, a Dutch, Polish or Portuguese code or a number of 3 to 6 digits
+// with an optional extension.
+var postalCodeToken = regexp.MustCompile(`(?i)\b([A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}|\d{4} ?[A-Z]{2}|\d{2}-\d{3}|\d{4}-\d{3}|\d{3,6}(-\d{4})?)\b`)
+
+// regionCode matches abbreviated regions, e.g. "IL" or "NSW".
+var regionCode = regexp.MustCompile(`^[A-Z]{2,3}$`)
+
+// countryCode matches the ISO 3166-1 alpha-2 country codes written at the end of an address, e.g. "NO".
+var countryCode = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// ParseAddress parses an address written as free text, e.g. "1 Main St, Springfield,
+// IL 62701, USA" or "Karl Johans gate 1\n0154 Oslo\nNorway", into its parts.
+//
+// Parts are separated by commas or line breaks. A trailing country name is
+// recognised, and so is a trailing ISO 3166-1 alpha-2 code if the part before it
+// holds a postal code: "DE" is the country in "10117 Berlin, DE" but the state in
+// "Dover, DE 19901" and "Dover, DE", and digits such as "75" are never a country.
+// A remaining last part that is only an abbreviated region becomes the region.
+// The postal code is then taken from the last part, with an abbreviated region
+// right before it ("IL 62701") becoming the region and any other text around it
+// the city ("0154 Oslo", "London SW1A 1AA"). The part before becomes the city if
+// none was found yet, and the rest make up the lines. Text that cannot be split
+// is kept as a single line, so no information is lost.
+//
+// Parameters:
+//   - s: The free-text address.
+//
+// Returns:
+//   - The parsed address, without coordinates. The zero Address if s is blank.
+func ParseAddress(s string) Address {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' })
+	for i := range parts {
+		parts[i] = strings.Join(strings.Fields(parts[i]), " ")
+	}
+	parts = slices.DeleteFunc(parts, func(part string) bool { return part == "" })
+	if len(parts) == 0 {
+		return Address{}
+	}
+
+	var address Address
+	if len(parts) > 1 {
+		last := parts[len(parts)-1]
+		if code, ok := countryNames[strings.ToLower(last)]; ok {
+			address.Country = code
+			parts = parts[:len(parts)-1]
+		} else if isCountryCode(last) && postalCodeToken.MatchString(parts[len(parts)-2]) {
+			address.Country = last
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	if len(parts) > 1 && regionCode.MatchString(parts[len(parts)-1]) {
+		address.Region = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+
+	if len(parts) > 1 {
+		last := parts[len(parts)-1]
+		if loc := postalCodeToken.FindStringIndex(last); loc != nil {
+			address.PostalCode = strings.ToUpper(last[loc[0]:loc[1]])
+			before, after := strings.TrimSpace(last[:loc[0]]), strings.TrimSpace(last[loc[1]:])
+			if words := strings.Fields(before); len(words) > 0 && address.Region == "" && regionCode.MatchString(words[len(words)-1]) {
+				address.Region = words[len(words)-1]
+				before = strings.Join(words[:len(words)-1], " ")
+			}
+			address.City = strings.TrimSpace(before + " " + after)
+			parts = parts[:len(parts)-1]
+		}
+	}
+
+	if address.City == "" && len(parts) > 1 {
+		address.City = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	address.Lines = parts
+
+	return address
+}
+
+// isCountryCode reports whether s is the ISO 3166-1 alpha-2 code of a country in upper case.
+func isCountryCode(s string) bool {
+	if !countryCode.MatchString(s) {
+		return false
+	}
+	region, err := language.ParseRegion(s)
+
+	return err == nil && region.IsCountry() && region.String() == s
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/representation"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Address
+	}{
+		{in: "  \n, "},
+		{
+			in:   "1 Main St, Springfield, IL 62701, USA",
+			want: Address{Lines: []string{"1 Main St"}, City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"},
+		},
+		{
+			in:   "1 Main St, Springfield, IL 62701, US",
+			want: Address{Lines: []string{"1 Main St"}, City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"},
+		},
+		{
+			in:   "1 Main St, Springfield, IL 62701",
+			want: Address{Lines: []string{"1 Main St"}, City: "Springfield", Region: "IL", PostalCode: "62701"},
+		},
+		{
+			// A state code that is also a country code, without a postal code
+			in:   "1 Main St, Springfield, IL",
+			want: Address{Lines: []string{"1 Main St"}, City: "Springfield", Region: "IL"},
+		},
+		{
+			in:   "1 Dover Green, Dover, DE",
+			want: Address{Lines: []string{"1 Dover Green"}, City: "Dover", Region: "DE"},
+		},
+		{
+			in:   "Unter den Linden 1, 10117 Berlin, DE",
+			want: Address{Lines: []string{"Unter den Linden 1"}, City: "Berlin", PostalCode: "10117", Country: "DE"},
+		},
+		{
+			// Too short to be a postal code, and not a country either
+			in:   "Rue de Rivoli 1, Paris, 75",
+			want: Address{Lines: []string{"Rue de Rivoli 1", "Paris"}, City: "75"},
+		},
+		{
+			in:   "Karl Johans gate 1\n0154 Oslo\nNorway",
+			want: Address{Lines: []string{"Karl Johans gate 1"}, City: "Oslo", PostalCode: "0154", Country: "NO"},
+		},
+		{
+			in:   "Karl Johans gate 1, 0154 Oslo, NO",
+			want: Address{Lines: []string{"Karl Johans gate 1"}, City: "Oslo", PostalCode: "0154", Country: "NO"},
+		},
+		{
+			in:   "10 Downing Street, London SW1A 2AA, United Kingdom",
+			want: Address{Lines: []string{"10 Downing Street"}, City: "London", PostalCode: "SW1A 2AA", Country: "GB"},
+		},
+		{
+			in:   "1 Main St, Sydney NSW 2000, Australia",
+			want: Address{Lines: []string{"1 Main St"}, City: "Sydney", Region: "NSW", PostalCode: "2000", Country: "AU"},
+		},
+		{
+			// XX is not assigned to a country
+			in:   "1 Main St, 12345 Town, XX",
+			want: Address{Lines: []string{"1 Main St"}, City: "Town", Region: "XX", PostalCode: "12345"},
+		},
+		{
+			in:   "Somewhere over the rainbow",
+			want: Address{Lines: []string{"Somewhere over the rainbow"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := ParseAddress(tt.in)
+			if len(got.Lines) == 0 {
+				got.Lines = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAddress(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAddressReadsString(t *testing.T) {
+	for _, address := range []Address{
+		{Lines: []string{"1 Main St", "Apt 2"}, City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"},
+		{Lines: []string{"Karl Johans gate 1"}, City: "Oslo", PostalCode: "0154", Country: "NO"},
+		{Lines: []string{"Calle Mayor 1"}, City: "Madrid", PostalCode: "28013", Country: "ES"},
+	} {
+		if got := ParseAddress(address.String()); !got.SameLocation(address) {
+			t.Errorf("ParseAddress(%q) = %#v, want %#v", address.String(), got, address)
+		}
+	}
+}
+
+// legacyUser is a user as stored before addresses were structured.
+type legacyUser struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	ID      string   `json:"id" xml:"id"`
+	Address string   `json:"address" xml:"address"`
+}
+
+func TestAddressDecodesLegacyStrings(t *testing.T) {
+	want := Address{Lines: []string{"1 Main St"}, City: "Springfield", Region: "IL", PostalCode: "62701", Country: "US"}
+	structured := User{ID: "1", Address: want}
+
+	for _, format := range representation.Formats {
+		t.Run(format.MediaType, func(t *testing.T) {
+			for name, v := range map[string]any{
+				"legacy":     legacyUser{ID: "1", Address: "1 Main St, Springfield, IL 62701, USA"},
+				"structured": structured,
+			} {
+				data, err := format.Marshal(v)
+				if err != nil {
+					t.Fatalf("%s: Marshal() error = %v", name, err)
+				}
+				var got User
+				if err := format.Unmarshal(data, &got); err != nil {
+					t.Fatalf("%s: Unmarshal() error = %v", name, err)
+				}
+				if got.ID != "1" || !reflect.DeepEqual(got.Address, want) {
+					t.Errorf("%s: Address = %#v, want %#v", name, got.Address, want)
+				}
+			}
+		})
+	}
+}
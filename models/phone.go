@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+)
+
+// ErrInvalidPhone is returned by NormalizePhone for text that is not a valid phone number.
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+// NormalizePhone parses a phone number written in any common format, e.g.
+// "(201) 555-0123", "+47 22 33 44 55" or "0044 20 7946 0018", and returns it in
+// E.164 format, e.g. "+12015550123", the form phone numbers are stored in.
+//
+// Numbers written without a country calling code are read as numbers of
+// defaultRegion. The number must be valid in the region it belongs to, i.e. have
+// the right length and an assigned prefix.
+//
+// Parameters:
+//   - number: The phone number as entered.
+//   - defaultRegion: The ISO 3166-1 alpha-2 code of the region of national numbers, e.g. "US".
+//
+// Returns:
+//   - The number in E.164 format.
+//   - An error matching ErrInvalidPhone if number is not a valid phone number.
+func NormalizePhone(number, defaultRegion string) (string, error) {
+	parsed, err := phonenumbers.Parse(number, strings.ToUpper(defaultRegion))
+	if err != nil {
+		return "", errors.Join(ErrInvalidPhone, err)
+	}
+	if !phonenumbers.IsValidNumber(parsed) {
+		return "", ErrInvalidPhone
+	}
+
+	return phonenumbers.Format(parsed, phonenumbers.E164), nil
+}
+
+// PhoneFormats holds a stored phone number formatted for display.
+type PhoneFormats struct {
+	// National is the number as dialled within its region, e.g. "(201) 555-0123".
+	National string `json:"national" xml:"national"`
+	// International is the number as dialled from abroad, e.g. "+1 201-555-0123".
+	International string `json:"international" xml:"international"`
+}
+
+// FormatPhone formats a phone number stored in E.164 format for display. Numbers
+// that cannot be parsed, such as ones stored before numbers were normalised, are
+// returned unchanged in both formats.
+func FormatPhone(number string) PhoneFormats {
+	parsed, err := phonenumbers.Parse(number, "")
+	if err != nil {
+		return PhoneFormats{National: number, International: number}
+	}
+
+	return PhoneFormats{
+		National:      phonenumbers.Format(parsed, phonenumbers.NATIONAL),
+		International: phonenumbers.Format(parsed, phonenumbers.INTERNATIONAL),
+	}
+}
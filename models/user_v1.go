@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// UserV1 is the representation of a User in version 1 of the API, which predates
+// structured addresses: the address is a single line of text. It is derived from a
+// User with NewUserV1; addresses written through version 1 are parsed with ParseAddress.
+type UserV1 struct {
+	// XMLName names the element of a user in XML representations.
+	XMLName xml.Name `json:"-" xml:"user"`
+	// ID is the unique identifier for the user, typically a UUID.
+	ID string `json:"id" xml:"id"`
+	// FirstName is the user's given name.
+	FirstName string `json:"first_name" xml:"first_name"`
+	// LastName is the user's family name or surname.
+	LastName string `json:"last_name" xml:"last_name"`
+	// Email is the user's unique email address, used for login and communication.
+	Email string `json:"email" xml:"email"`
+	// Phone is the user's primary phone number in E.164 format, e.g. "+12015550123".
+	Phone string `json:"phone" xml:"phone"`
+	// PhoneFormats holds Phone formatted for display.
+	PhoneFormats PhoneFormats `json:"phone_formats" xml:"phone_formats"`
+	// Address is the user's physical address, formatted by Address.String.
+	Address string `json:"address" xml:"address"`
+	// Active indicates whether the user's account is currently active (true) or inactive (false).
+	Active bool `json:"active" xml:"active"`
+	// Preferences embeds the notification settings for the user.
+	Preferences Preferences `json:"preferences" xml:"preferences"`
+	// CreatedAt records the exact date and time when the user record was created in the system.
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	// UpdatedAt records the exact date and time when the user record was last modified.
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at"`
+}
+
+// NewUserV1 returns the version 1 representation of user.
+func NewUserV1(user User) UserV1 {
+	return UserV1{
+		ID:           user.ID,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Email:        user.Email,
+		Phone:        user.Phone,
+		PhoneFormats: FormatPhone(user.Phone),
+		Address:      user.Address.String(),
+		Active:       user.Active,
+		Preferences:  user.Preferences,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
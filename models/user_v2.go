@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// UserStatus is the state of a user's account in version 2 of the API.
+type UserStatus string
+
+const (
+	// UserStatusActive marks an account that can be used.
+	UserStatusActive UserStatus = "active"
+	// UserStatusInactive marks a disabled account.
+	UserStatusInactive UserStatus = "inactive"
+)
+
+// Name holds the parts of a user's name.
+type Name struct {
+	// First is the user's given name.
+	First string `json:"first" xml:"first"`
+	// Last is the user's family name or surname.
+	Last string `json:"last" xml:"last"`
+}
+
+// Contact holds the details used to reach a user.
+type Contact struct {
+	// Email is the user's unique email address, used for login and communication.
+	Email string `json:"email" xml:"email"`
+	// Phone is the user's primary phone number in E.164 format, e.g. "+12015550123".
+	Phone string `json:"phone" xml:"phone"`
+	// PhoneFormats holds Phone formatted for display.
+	PhoneFormats PhoneFormats `json:"phone_formats" xml:"phone_formats"`
+}
+
+// UserV2 is the representation of a User in version 2 of the API. Compared to
+// User it groups the name and contact details, reports the account state as a
+// UserStatus instead of a boolean, names the preferences after what they control
+// and returns the address structured. It is derived from a User with NewUserV2;
+// the store keeps Users.
+type UserV2 struct {
+	// XMLName names the element of a user in XML representations.
+	XMLName xml.Name `json:"-" xml:"user"`
+	// ID is the unique identifier for the user, typically a UUID.
+	ID string `json:"id" xml:"id"`
+	// Name holds the user's given and family names.
+	Name Name `json:"name" xml:"name"`
+	// Contact holds the user's email address and phone number.
+	Contact Contact `json:"contact" xml:"contact"`
+	// Address is the user's physical address.
+	Address Address `json:"address" xml:"address"`
+	// Status is the state of the user's account.
+	Status UserStatus `json:"status" xml:"status" enums:"active,inactive"`
+	// Notifications holds the channels the user wants to be notified on.
+	Notifications Preferences `json:"notifications" xml:"notifications"`
+	// CreatedAt records the exact date and time when the user record was created in the system.
+	CreatedAt time.Time `json:"created_at" xml:"created_at"`
+	// UpdatedAt records the exact date and time when the user record was last modified.
+	UpdatedAt time.Time `json:"updated_at" xml:"updated_at"`
+}
+
+// NewUserV2 returns the version 2 representation of user.
+func NewUserV2(user User) UserV2 {
+	status := UserStatusInactive
+	if user.Active {
+		status = UserStatusActive
+	}
+
+	return UserV2{
+		ID:            user.ID,
+		Name:          Name{First: user.FirstName, Last: user.LastName},
+		Contact:       Contact{Email: user.Email, Phone: user.Phone, PhoneFormats: FormatPhone(user.Phone)},
+		Address:       user.Address,
+		Status:        status,
+		Notifications: user.Preferences,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
@@ -0,0 +1,84 @@
+// Package problem implements the "Problem Details for HTTP APIs" error model of
+// RFC 9457. Every error response of the service is a Problem rendered as
+// `application/problem+json`, so clients can handle failures in a single way.
+package problem
+
+import (
+	"net/http"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+)
+
+// ContentType is the media type of a serialized Problem.
+const ContentType = "application/problem+json"
+
+// DefaultType is the problem type used when a problem has no more specific type.
+// As defined by RFC 9457, its Title is the HTTP status phrase.
+const DefaultType = "about:blank"
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	// Field is the name of the rejected field.
+	Field string `json:"field"`
+	// Detail explains why the value of the field was rejected.
+	Detail string `json:"detail"`
+}
+
+// Problem is an RFC 9457 problem details object, extended with the request's
+// correlation identifiers and the field errors of a rejected request body.
+type Problem struct {
+	// Type is a URI reference identifying the problem type. Defaults to DefaultType.
+	Type string `json:"type"`
+	// Title is a short, human-readable summary of the problem type.
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response.
+	Status int `json:"status"`
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string `json:"detail,omitempty"`
+	// Instance is a URI reference identifying this occurrence, the path of the failed request.
+	Instance string `json:"instance,omitempty"`
+	// Code is a machine-readable error code refining Type, such as a service error code.
+	Code string `json:"code,omitempty"`
+	// RequestID is the request's correlation ID (see correlation.RequestIDHeader).
+	RequestID string `json:"request_id,omitempty"`
+	// RayID is the Cloudflare Ray ID of the request, when it came through Cloudflare.
+	RayID string `json:"cf_ray,omitempty"`
+	// Errors lists the rejected fields of a request that failed validation.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// New returns a Problem of DefaultType for the HTTP status code status, titled
+// with the status phrase.
+//
+// Parameters:
+//   - status: The HTTP status code of the response.
+//   - detail: The explanation of this occurrence, may be empty.
+//
+// Returns:
+//   - A pointer to the new Problem.
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   DefaultType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// WithErrors sets the field errors of p and returns p.
+func (p *Problem) WithErrors(errors []FieldError) *Problem {
+	p.Errors = errors
+
+	return p
+}
+
+// ForRequest fills in the Instance of p with the path of r and the correlation
+// identifiers stored in r's context by the RequestID middleware, and returns p.
+func (p *Problem) ForRequest(r *http.Request) *Problem {
+	ids := correlation.FromContext(r.Context())
+	p.Instance = r.URL.Path
+	p.RequestID = ids.RequestID
+	p.RayID = ids.RayID
+
+	return p
+}
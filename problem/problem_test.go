@@ -0,0 +1,51 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+)
+
+func TestProblemJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v2/users?x=1", nil)
+	req = req.WithContext(correlation.WithIDs(req.Context(), correlation.IDs{RequestID: "req-1", RayID: "8a1b2c3d4e5f6a7b-AMS"}))
+
+	tests := []struct {
+		name    string
+		problem *Problem
+		want    string
+	}{
+		{
+			name:    "default type",
+			problem: New(http.StatusNotFound, "").ForRequest(httptest.NewRequest(http.MethodGet, "/users/1", nil)),
+			want:    `{"type":"about:blank","title":"Not Found","status":404,"instance":"/users/1"}`,
+		},
+		{
+			name: "correlated with field errors",
+			problem: New(http.StatusBadRequest, "Validation failed").
+				WithErrors([]FieldError{{Field: "email", Detail: "email must be a valid email address"}}).
+				ForRequest(req),
+			want: `{"type":"about:blank","title":"Bad Request","status":400,"detail":"Validation failed","instance":"/v2/users",` +
+				`"request_id":"req-1","cf_ray":"8a1b2c3d4e5f6a7b-AMS","errors":[{"field":"email","detail":"email must be a valid email address"}]}`,
+		},
+		{
+			name:    "coded",
+			problem: &Problem{Type: DefaultType, Title: "Conflict", Status: http.StatusConflict, Code: "conflict"},
+			want:    `{"type":"about:blank","title":"Conflict","status":409,"code":"conflict"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.problem)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable storage
+// backends, so limits can be enforced per process (in memory) or shared across
+// instances (Redis or any Redis-compatible server).
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLimit is returned when a limit definition cannot be parsed.
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit describes a token bucket: Requests tokens are refilled evenly over Period,
+// and the bucket holds at most Burst tokens.
+type Limit struct {
+	// Requests is the number of requests allowed per Period.
+	Requests int
+	// Period is the window over which Requests are refilled.
+	Period time.Duration
+	// Burst is the bucket capacity. Defaults to Requests when zero.
+	Burst int
+}
+
+// ParseLimit parses a limit in the form "<requests>/<period>[:<burst>]",
+// for example "10/1m" or "100/1s:200". The period uses time.ParseDuration syntax.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	spec, burstPart, hasBurst := strings.Cut(value, ":")
+	requestsPart, periodPart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w %q: expected <requests>/<period>[:<burst>]", ErrInvalidLimit, value)
+	}
+
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsPart))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: requests must be a positive integer", ErrInvalidLimit, value)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodPart))
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: period must be a positive duration", ErrInvalidLimit, value)
+	}
+
+	limit := Limit{Requests: requests, Period: period, Burst: requests}
+	if hasBurst {
+		burst, err := strconv.Atoi(strings.TrimSpace(burstPart))
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("%w %q: burst must be a positive integer", ErrInvalidLimit, value)
+		}
+		limit.Burst = burst
+	}
+
+	return limit, nil
+}
+
+// Decode implements envconfig.Decoder so a Limit can be loaded directly from an
+// environment variable. An empty value leaves the limit disabled.
+func (l *Limit) Decode(value string) error {
+	if strings.TrimSpace(value) == "" {
+		*l = Limit{}
+
+		return nil
+	}
+	parsed, err := ParseLimit(value)
+	if err != nil {
+		return err
+	}
+	*l = parsed
+
+	return nil
+}
+
+// Enabled reports whether the limit is configured.
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// capacity returns the bucket size, falling back to Requests when Burst is unset.
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Requests
+}
+
+// ratePerSecond returns the refill rate of the bucket in tokens per second.
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// String renders the limit in the format accepted by ParseLimit.
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s:%d", l.Requests, l.Period, l.capacity())
+}
+
+// Routes maps a route, written as "<METHOD> <route template>" (e.g. "POST /users"),
+// to the limit enforced on it.
+type Routes map[string]Limit
+
+// Decode implements envconfig.Decoder. The value is a comma separated list of
+// "<METHOD> <route>=<limit>" entries, for example
+// "POST /users=10/1m,GET /users=100/1m:200".
+func (r *Routes) Decode(value string) error {
+	routes := Routes{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, spec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return fmt.Errorf("%w %q: expected <METHOD> <route>=<limit>", ErrInvalidLimit, entry)
+		}
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return err
+		}
+		routes[strings.Join(strings.Fields(route), " ")] = limit
+	}
+	*r = routes
+
+	return nil
+}
+
+// Result reports the outcome of a single Allow call.
+type Result struct {
+	// Allowed is true if the request may proceed.
+	Allowed bool
+	// Limit is the bucket capacity.
+	Limit int
+	// Remaining is the number of whole tokens left after this call.
+	Remaining int
+	// ResetAfter is the time until the bucket is completely refilled.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next token is available. Zero when Allowed.
+	RetryAfter time.Duration
+}
+
+// Store is a rate limiting backend. Implementations must be safe for concurrent use.
+type Store interface {
+	// Allow takes one token from the bucket identified by key, creating it full
+	// if it does not exist yet, and reports whether the request is allowed.
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// bucketState is the token bucket math shared by all backends. Given the stored
+// token count, the time of the last update and the current time, it refills the
+// bucket, tries to take one token and returns the new token count with the result.
+func bucketState(limit Limit, tokens float64, last, now time.Time) (float64, Result) {
+	capacity := float64(limit.capacity())
+	rate := limit.ratePerSecond()
+
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = min(capacity, tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: limit.capacity()}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	result.Remaining = int(tokens)
+	result.ResetAfter = secondsToDuration((capacity - tokens) / rate)
+
+	return tokens, result
+}
+
+// secondsToDuration converts fractional seconds to a time.Duration.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Limit
+		wantErr bool
+	}{
+		{value: "10/1m", want: Limit{Requests: 10, Period: time.Minute, Burst: 10}},
+		{value: " 100/1s:200 ", want: Limit{Requests: 100, Period: time.Second, Burst: 200}},
+		{value: "10", wantErr: true},
+		{value: "0/1m", wantErr: true},
+		{value: "10/forever", wantErr: true},
+		{value: "10/-1s", wantErr: true},
+		{value: "10/1m:0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseLimit(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidLimit) {
+					t.Errorf("ParseLimit() error = %v, want ErrInvalidLimit", err)
+				}
+
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseLimit() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutesDecode(t *testing.T) {
+	var routes Routes
+	if err := routes.Decode("POST  /users=10/1m, GET /users=100/1m:200,"); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	want := Routes{
+		"POST /users": {Requests: 10, Period: time.Minute, Burst: 10},
+		"GET /users":  {Requests: 100, Period: time.Minute, Burst: 200},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("Decode() = %v, want %v", routes, want)
+	}
+	for route, limit := range want {
+		if routes[route] != limit {
+			t.Errorf("routes[%q] = %+v, want %+v", route, routes[route], limit)
+		}
+	}
+
+	if err := routes.Decode("POST /users"); !errors.Is(err, ErrInvalidLimit) {
+		t.Errorf("Decode() of an entry without limit: error = %v, want ErrInvalidLimit", err)
+	}
+}
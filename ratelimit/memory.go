@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval controls how often idle buckets are evicted from a MemoryStore.
+const sweepInterval = time.Minute
+
+// bucket is the in-memory state of a single token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// idleAfter is the point in time after which the bucket is full again and
+	// can be dropped without changing behaviour.
+	idleAfter time.Time
+}
+
+// MemoryStore is a Store that keeps buckets in process memory. Limits are
+// enforced per instance, so it is best suited to single instance deployments
+// and local development.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates an empty in-memory rate limiting backend.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow implements Store.
+//
+// This function is safe for concurrent use.
+func (s *MemoryStore) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.capacity()), last: now}
+		s.buckets[key] = b
+	}
+
+	tokens, result := bucketState(limit, b.tokens, b.last, now)
+	b.tokens = tokens
+	b.last = now
+	b.idleAfter = now.Add(result.ResetAfter)
+
+	return result, nil
+}
+
+// sweep drops buckets that have refilled completely, bounding memory usage by the
+// number of recently active clients. It runs at most once per sweepInterval.
+// The caller must hold s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.After(b.idleAfter) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript atomically refills and takes a token from the bucket stored
+// as a hash at KEYS[1]. It mirrors bucketState so all backends behave the same.
+//
+// ARGV: capacity, rate (tokens per second), now (unix milliseconds).
+// Returns: {allowed (0/1), tokens remaining * 1000}.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1])
+local last = tonumber(state[2])
+if tokens == nil then
+  tokens = capacity
+  last = now
+end
+
+local elapsed = math.max(0, now - last) / 1000
+tokens = math.min(capacity, tokens + elapsed * rate)
+
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) / rate * 1000) + 1000)
+
+return {allowed, math.floor(tokens * 1000)}
+`)
+
+// RedisStore is a Store backed by Redis or any server speaking the Redis protocol
+// with Lua scripting support. Buckets are shared by every instance using the
+// same server, and expire automatically once they have refilled.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore creates a Redis backed rate limiting store.
+//
+// Parameters:
+//   - client: Any go-redis client (single node, cluster, ring) or a client connected
+//     to a local Redis stand-in for testing.
+//   - prefix: Prepended to every bucket key to namespace them within the database.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{
+		client: client,
+		prefix: prefix,
+		now:    time.Now,
+	}
+}
+
+// Allow implements Store.
+//
+// This function is safe for concurrent use.
+func (s *RedisStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{s.prefix + key},
+		limit.capacity(), limit.ratePerSecond(), s.now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit script for %q: %w", key, err)
+	}
+	if len(values) != 2 {
+		return Result{}, fmt.Errorf("rate limit script for %q: unexpected reply %v", key, values)
+	}
+
+	tokens := float64(values[1]) / 1000
+	rate := limit.ratePerSecond()
+	result := Result{
+		Allowed:    values[0] == 1,
+		Limit:      limit.capacity(),
+		Remaining:  int(tokens),
+		ResetAfter: secondsToDuration((float64(limit.capacity()) - tokens) / rate),
+	}
+	if !result.Allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	return result, nil
+}
+
+// Ping verifies that the Redis server is reachable.
+func (s *RedisStore) Ping(ctx context.Context) error {
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("ping rate limit backend: %w", err)
+	}
+
+	return nil
+}
+
+// Close releases the connections of the underlying client.
+func (s *RedisStore) Close() error {
+	if err := s.client.Close(); err != nil {
+		return fmt.Errorf("close rate limit backend: %w", err)
+	}
+
+	return nil
+}
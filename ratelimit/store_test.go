@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeClock is a settable time source for the stores.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// newTestStores returns a MemoryStore and a RedisStore backed by miniredis, both
+// reading the time from clock, keyed by name.
+func newTestStores(t *testing.T, clock *fakeClock) map[string]Store {
+	t.Helper()
+
+	memory := NewMemoryStore()
+	memory.now = clock.Now
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	redisStore := NewRedisStore(client, "test:")
+	redisStore.now = clock.Now
+
+	return map[string]Store{"memory": memory, "redis": redisStore}
+}
+
+func TestStoreAllow(t *testing.T) {
+	// One token per second, up to two at a time
+	limit := Limit{Requests: 2, Period: 2 * time.Second, Burst: 2}
+
+	steps := []struct {
+		name          string
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+		wantRetry     time.Duration
+	}{
+		{name: "first request takes from a full bucket", at: 0, wantAllowed: true, wantRemaining: 1, wantReset: time.Second},
+		{name: "burst empties the bucket", at: 0, wantAllowed: true, wantRemaining: 0, wantReset: 2 * time.Second},
+		{name: "empty bucket rejects", at: 0, wantAllowed: false, wantRemaining: 0, wantReset: 2 * time.Second, wantRetry: time.Second},
+		{name: "half a token is not enough", at: 500 * time.Millisecond, wantAllowed: false, wantRemaining: 0, wantReset: 1500 * time.Millisecond, wantRetry: 500 * time.Millisecond},
+		{name: "refilled token is taken", at: time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 2 * time.Second},
+		{name: "refill stops at capacity", at: 10 * time.Second, wantAllowed: true, wantRemaining: 1, wantReset: time.Second},
+	}
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	for name, store := range newTestStores(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			for _, step := range steps {
+				clock.now = start.Add(step.at)
+				result, err := store.Allow(context.Background(), "client", limit)
+				if err != nil {
+					t.Fatalf("%s: Allow() error = %v", step.name, err)
+				}
+				want := Result{
+					Allowed:    step.wantAllowed,
+					Limit:      2,
+					Remaining:  step.wantRemaining,
+					ResetAfter: step.wantReset,
+					RetryAfter: step.wantRetry,
+				}
+				if result != want {
+					t.Errorf("%s: Allow() = %+v, want %+v", step.name, result, want)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreAllowSeparatesKeys(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Minute}
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	for name, store := range newTestStores(t, clock) {
+		t.Run(name, func(t *testing.T) {
+			for _, key := range []string{"a", "b"} {
+				result, err := store.Allow(context.Background(), key, limit)
+				if err != nil || !result.Allowed {
+					t.Errorf("Allow(%q) = %+v, %v, want allowed", key, result, err)
+				}
+			}
+			if result, _ := store.Allow(context.Background(), "a", limit); result.Allowed {
+				t.Error("Allow(\"a\") allowed a second request, want the bucket of a to be empty")
+			}
+		})
+	}
+}
+
+func TestRedisStoreExpiresRefilledBuckets(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	store := NewRedisStore(client, "test:")
+
+	limit := Limit{Requests: 1, Period: time.Second, Burst: 5}
+	if _, err := store.Allow(context.Background(), "client", limit); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+
+	// One token is missing, refilled after a second; the key lives one second longer
+	if ttl := server.TTL("test:client"); ttl != 2*time.Second {
+		t.Errorf("TTL = %v, want %v", ttl, 2*time.Second)
+	}
+	server.FastForward(2 * time.Second)
+	if server.Exists("test:client") {
+		t.Error("bucket still stored after it refilled")
+	}
+}
+
+func TestMemoryStoreSweepsRefilledBuckets(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	store := NewMemoryStore()
+	store.now = clock.Now
+
+	limit := Limit{Requests: 1, Period: time.Second}
+	for _, key := range []string{"a", "b"} {
+		if _, err := store.Allow(context.Background(), key, limit); err != nil {
+			t.Fatalf("Allow(%q) error = %v", key, err)
+		}
+	}
+
+	clock.now = start.Add(sweepInterval + time.Second)
+	if _, err := store.Allow(context.Background(), "c", limit); err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	if len(store.buckets) != 1 {
+		t.Errorf("%d buckets stored, want only the one of the active client", len(store.buckets))
+	}
+}
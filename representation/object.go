@@ -0,0 +1,216 @@
+package representation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Object is a resource whose fields are only known at run time, such as a sparse
+// fieldset projection (see package fieldset). It is encoded in every Format like a
+// struct with the same fields: in order, except in CBOR, whose map keys are sorted
+// canonically. Nested objects are Object values as well.
+//
+// Object is a plain value: it does not create types at run time, so building one
+// per request keeps no memory beyond the request.
+type Object struct {
+	// XMLName is the element name of the object in XML, like the XMLName field of a
+	// struct. Empty keeps the name given by the enclosing element.
+	XMLName string
+	// Fields lists the fields of the object in order.
+	Fields []Field
+}
+
+// Field is a single field of an Object.
+type Field struct {
+	// Name is the name of the field in every format except XML, as in a `json` tag.
+	Name string
+	// XMLName is the element name of the field in XML, as in an `xml` tag: "a>b"
+	// nests the element in <a>, and "-" leaves the field out of XML.
+	XMLName string
+	// OmitEmpty leaves the field out when Value is false, 0, a nil pointer or
+	// interface, or an empty string, slice or map, like the omitempty tag option.
+	OmitEmpty bool
+	// Value is the value of the field, encoded as it would be in a struct.
+	Value any
+}
+
+// omitted reports whether the field is left out of the encoding.
+func (f Field) omitted() bool {
+	if !f.OmitEmpty {
+		return false
+	}
+
+	rv := reflect.ValueOf(f.Value)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Struct:
+		return false
+	default:
+		return rv.IsZero()
+	}
+}
+
+// MarshalJSON encodes o as a JSON object with its fields in order. YAML is
+// derived from it.
+func (o Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, field := range o.Fields {
+		if field.omitted() {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// MarshalXML encodes o as an element named XMLName, or start if XMLName is empty,
+// holding an element per field in order.
+func (o Object) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if o.XMLName != "" {
+		start.Name = xml.Name{Local: o.XMLName}
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, field := range o.Fields {
+		if field.XMLName == "-" || field.omitted() {
+			continue
+		}
+		if err := encodeXMLField(e, field); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// encodeXMLField encodes the value of field in the element named by its XMLName,
+// nested in the parent elements the name lists.
+func encodeXMLField(e *xml.Encoder, field Field) error {
+	names := strings.Split(field.XMLName, ">")
+	parents := make([]xml.StartElement, 0, len(names)-1)
+	for _, name := range names[:len(names)-1] {
+		parent := xml.StartElement{Name: xml.Name{Local: name}}
+		if err := e.EncodeToken(parent); err != nil {
+			return err
+		}
+		parents = append(parents, parent)
+	}
+	if err := e.EncodeElement(field.Value, xml.StartElement{Name: xml.Name{Local: names[len(names)-1]}}); err != nil {
+		return err
+	}
+	for _, parent := range slices.Backward(parents) {
+		if err := e.EncodeToken(parent.End()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keyValues is a map encoded by the MessagePack codec from alternating keys and
+// values, keeping their order.
+type keyValues []any
+
+// MapBySlice marks keyValues as a map for the MessagePack codec.
+func (keyValues) MapBySlice() {}
+
+// CodecEncodeSelf encodes o as a MessagePack map with its fields in order.
+func (o *Object) CodecEncodeSelf(e *codec.Encoder) {
+	pairs := make(keyValues, 0, 2*len(o.Fields))
+	for _, field := range o.Fields {
+		if !field.omitted() {
+			pairs = append(pairs, field.Name, field.Value)
+		}
+	}
+	e.MustEncode(pairs)
+}
+
+// CodecDecodeSelf decodes a MessagePack map into the fields of o, in order.
+func (o *Object) CodecDecodeSelf(d *codec.Decoder) {
+	var pairs keyValues
+	d.MustDecode(&pairs)
+	o.Fields = make([]Field, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		name, _ := pairs[i].(string)
+		o.Fields = append(o.Fields, Field{Name: name, XMLName: name, Value: pairs[i+1]})
+	}
+}
+
+// MarshalCBOR encodes o as a CBOR map with canonically sorted keys, the way
+// structs are encoded.
+func (o Object) MarshalCBOR() ([]byte, error) {
+	type pair struct{ key, value []byte }
+	pairs := make([]pair, 0, len(o.Fields))
+	for _, field := range o.Fields {
+		if field.omitted() {
+			continue
+		}
+		key, err := cborEncMode.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := cborEncMode.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, pair{key: key, value: value})
+	}
+	// Canonical order (RFC 7049, section 3.9): shorter keys first, then bytewise
+	slices.SortFunc(pairs, func(a, b pair) int {
+		if len(a.key) != len(b.key) {
+			return len(a.key) - len(b.key)
+		}
+
+		return bytes.Compare(a.key, b.key)
+	})
+
+	data := cborMapHeader(len(pairs))
+	for _, p := range pairs {
+		data = append(data, p.key...)
+		data = append(data, p.value...)
+	}
+
+	return data, nil
+}
+
+// cborMapHeader returns the head of a CBOR map (major type 5) of n pairs.
+func cborMapHeader(n int) []byte {
+	const major = 5 << 5
+	switch {
+	case n < 24:
+		return []byte{major | byte(n)}
+	case n <= 0xff:
+		return []byte{major | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major | 25}, uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major | 26}, uint32(n))
+	}
+}
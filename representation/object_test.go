@@ -0,0 +1,102 @@
+package representation
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testEntry is a resource with the fields of the Objects in the tests.
+type testEntry struct {
+	XMLName   xml.Name    `json:"-" xml:"entry"`
+	Name      string      `json:"name" xml:"name"`
+	Tags      []string    `json:"tags" xml:"tags>tag"`
+	Address   testAddress `json:"address" xml:"address"`
+	Note      string      `json:"note,omitempty" xml:"note,omitempty"`
+	Owner     *string     `json:"owner,omitempty" xml:"owner,omitempty"`
+	CreatedAt time.Time   `json:"created_at" xml:"created_at"`
+	Count     int         `json:"count" xml:"count"`
+}
+
+func TestObjectEncodesLikeStruct(t *testing.T) {
+	entry := testEntry{
+		Name:      "Ada",
+		Tags:      []string{"admin", "beta"},
+		Address:   testAddress{City: "Oslo", Country: "NO"},
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC),
+		Count:     42,
+	}
+	object := Object{
+		XMLName: "entry",
+		Fields: []Field{
+			{Name: "name", XMLName: "name", Value: entry.Name},
+			{Name: "tags", XMLName: "tags>tag", Value: entry.Tags},
+			{Name: "address", XMLName: "address", Value: Object{Fields: []Field{
+				{Name: "city", XMLName: "city", Value: entry.Address.City},
+				{Name: "country", XMLName: "country", Value: entry.Address.Country},
+			}}},
+			{Name: "note", XMLName: "note", OmitEmpty: true, Value: entry.Note},
+			{Name: "owner", XMLName: "owner", OmitEmpty: true, Value: entry.Owner},
+			{Name: "created_at", XMLName: "created_at", Value: entry.CreatedAt},
+			{Name: "count", XMLName: "count", Value: entry.Count},
+		},
+	}
+
+	for _, format := range Formats {
+		t.Run(format.MediaType, func(t *testing.T) {
+			for name, v := range map[string][2]any{
+				"object": {object, entry},
+				"list":   {[]Object{object, object}, []testEntry{entry, entry}},
+			} {
+				got, err := format.Marshal(v[0])
+				if err != nil {
+					t.Fatalf("%s: Marshal() error = %v", name, err)
+				}
+				want, err := format.Marshal(v[1])
+				if err != nil {
+					t.Fatalf("%s: Marshal() of the struct error = %v", name, err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s: Marshal() = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestObjectXMLName(t *testing.T) {
+	tests := []struct {
+		name   string
+		object Object
+		want   string
+	}{
+		{
+			name:   "named",
+			object: Object{XMLName: "user", Fields: []Field{{Name: "id", XMLName: "id", Value: 1}}},
+			want:   "<items><user><id>1</id></user></items>",
+		},
+		{
+			name:   "unnamed takes the enclosing name",
+			object: Object{Fields: []Field{{Name: "id", XMLName: "id", Value: 1}}},
+			want:   "<items><Items><id>1</id></Items></items>",
+		},
+		{
+			name:   "excluded field",
+			object: Object{XMLName: "user", Fields: []Field{{Name: "id", XMLName: "-", Value: 1}}},
+			want:   "<items><user></user></items>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := XML.Marshal([]Object{tt.object})
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if got := strings.TrimPrefix(string(data), xml.Header); got != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
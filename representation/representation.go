@@ -0,0 +1,393 @@
+// Package representation encodes and decodes API resources in the media types
+// the service supports (JSON, XML, YAML, MessagePack and CBOR) and negotiates
+// which one to use from the Accept and Content-Type request headers.
+//
+// Every format except XML derives its field names from the `json` struct tags,
+// so a resource has the same shape in all of them; XML uses `xml` tags, which
+// must mirror the `json` tags. Those formats also decode through JSON, so types
+// with their own JSON decoding (such as models.Address reading legacy addresses)
+// decode the same way in each of them. Timestamps use each format's native time type
+// where it has one (MessagePack timestamp extension, CBOR tag 0) and RFC 3339
+// strings otherwise.
+package representation
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a media type the API can represent resources in.
+type Format struct {
+	// MediaType is the canonical media type of the format, e.g. "application/json".
+	MediaType string
+	// Aliases lists other media types accepted for the format, e.g. "text/xml".
+	Aliases []string
+	// ContentType is the Content-Type header value of responses in the format.
+	ContentType string
+	// Marshal encodes v in the format.
+	Marshal func(v any) ([]byte, error)
+	// Unmarshal decodes data in the format into v.
+	Unmarshal func(data []byte, v any) error
+}
+
+var (
+	// JSON is the default format, used when the client expresses no preference.
+	JSON = Format{
+		MediaType:   "application/json",
+		ContentType: "application/json; charset=utf-8",
+		Marshal:     json.Marshal,
+		Unmarshal:   json.Unmarshal,
+	}
+	// XML represents resources as XML documents. Top-level lists are wrapped in an <items> element.
+	XML = Format{
+		MediaType:   "application/xml",
+		Aliases:     []string{"text/xml"},
+		ContentType: "application/xml; charset=utf-8",
+		Marshal:     marshalXML,
+		Unmarshal:   xml.Unmarshal,
+	}
+	// YAML represents resources as YAML documents.
+	YAML = Format{
+		MediaType:   "application/yaml",
+		Aliases:     []string{"application/x-yaml", "text/yaml"},
+		ContentType: "application/yaml; charset=utf-8",
+		Marshal:     marshalYAML,
+		Unmarshal:   unmarshalYAML,
+	}
+	// MsgPack represents resources as MessagePack.
+	MsgPack = Format{
+		MediaType:   "application/msgpack",
+		Aliases:     []string{"application/x-msgpack", "application/vnd.msgpack"},
+		ContentType: "application/msgpack",
+		Marshal:     marshalMsgPack,
+		Unmarshal:   unmarshalMsgPack,
+	}
+	// CBOR represents resources as CBOR (RFC 8949).
+	CBOR = Format{
+		MediaType:   "application/cbor",
+		ContentType: "application/cbor",
+		Marshal:     cborEncMode.Marshal,
+		Unmarshal:   unmarshalCBOR,
+	}
+)
+
+// Formats lists the supported formats in order of server preference.
+var Formats = []Format{JSON, XML, YAML, MsgPack, CBOR}
+
+// MediaTypes returns the canonical media types of Formats, e.g. for error messages.
+func MediaTypes() []string {
+	types := make([]string, len(Formats))
+	for i, format := range Formats {
+		types[i] = format.MediaType
+	}
+
+	return types
+}
+
+// matches reports whether mediaType, lower-cased and without parameters, names format.
+func (f Format) matches(mediaType string) bool {
+	if mediaType == f.MediaType {
+		return true
+	}
+	for _, alias := range f.Aliases {
+		if mediaType == alias {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ForContentType returns the format of a request body with the given Content-Type
+// header value. An empty value selects JSON, for clients that omit the header.
+// It returns false when the media type is not supported.
+func ForContentType(contentType string) (Format, bool) {
+	if strings.TrimSpace(contentType) == "" {
+		return JSON, true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Format{}, false
+	}
+	for _, format := range Formats {
+		if format.matches(mediaType) {
+			return format, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// acceptRange is a single media range of an Accept header.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+	params    map[string]string
+}
+
+// Negotiate picks the response format from an Accept header value. The media range
+// with the highest q-value wins; among ranges with the same q-value, a more specific
+// one ("application/xml") wins over a wildcard ("application/*", "*/*"), and formats
+// matched only by wildcards are chosen in the order of Formats. An empty header
+// selects JSON. It returns false when no supported format is acceptable.
+//
+// The parameters of the media range that selected the format, other than q, are
+// returned as well (e.g. {"envelope": "v1"} for "application/json; envelope=v1"),
+// so that clients can opt in to variants of a representation. They are nil for an
+// empty header.
+func Negotiate(accept string) (Format, map[string]string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, nil, true
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = parsed
+			}
+		}
+		delete(params, "q")
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality, params: params})
+	}
+
+	best, bestParams, bestQuality, bestSpecificity := Format{}, map[string]string(nil), 0.0, -1
+	for _, format := range Formats {
+		// The most specific range matching the format decides its quality
+		quality, params, specificity := 0.0, map[string]string(nil), -1
+		for _, r := range ranges {
+			if s := rangeSpecificity(r.mediaType, format); s > specificity {
+				quality, params, specificity = r.quality, r.params, s
+			}
+		}
+		if specificity < 0 || quality <= 0 {
+			continue
+		}
+		if quality > bestQuality || (quality == bestQuality && specificity > bestSpecificity) {
+			best, bestParams, bestQuality, bestSpecificity = format, params, quality, specificity
+		}
+	}
+
+	return best, bestParams, bestQuality > 0
+}
+
+// rangeSpecificity returns how specifically mediaRange matches format: 2 for the
+// media type itself, 1 for "type/*", 0 for "*/*" and -1 if it does not match.
+func rangeSpecificity(mediaRange string, format Format) int {
+	switch {
+	case format.matches(mediaRange):
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*"):
+		prefix := strings.TrimSuffix(mediaRange, "*")
+		if strings.HasPrefix(format.MediaType, prefix) {
+			return 1
+		}
+		for _, alias := range format.Aliases {
+			if strings.HasPrefix(alias, prefix) {
+				return 1
+			}
+		}
+	}
+
+	return -1
+}
+
+// xmlList wraps a top-level list, which is not a well-formed XML document on its own.
+type xmlList struct {
+	XMLName xml.Name `xml:"items"`
+	Items   any
+}
+
+// marshalXML encodes v as an XML document with a declaration.
+func marshalXML(v any) ([]byte, error) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		v = xmlList{Items: v}
+	}
+	data, err := xml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
+
+// marshalYAML encodes v as YAML with the field names and order of its JSON encoding,
+// by reading the JSON document (which is valid YAML) into a node tree and writing
+// the tree back in block style.
+func marshalYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	resetStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// resetStyle clears the flow and quoting styles taken over from JSON, so the
+// node tree is written in YAML's default block style. Strings that would read as
+// another type unquoted stay double-quoted, including the YAML 1.1 booleans
+// ("NO", "yes", "on", ...) that older parsers still resolve, such as the country
+// code of Norway.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && needsQuotes(node.Value) {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+// needsQuotes reports whether the YAML encoder quotes the string value, i.e.
+// whether it would not read back as the same string unquoted.
+func needsQuotes(value string) bool {
+	data, err := yaml.Marshal(value)
+
+	return err == nil && len(data) > 0 && (data[0] == '"' || data[0] == '\'')
+}
+
+// unmarshalYAML decodes a YAML document into v using v's JSON field names, by
+// converting the document to JSON first.
+func unmarshalYAML(data []byte, v any) error {
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	return unmarshalDocument(document, v)
+}
+
+// unmarshalDocument decodes document, a value decoded from any format into an
+// empty interface, into v as if it were a JSON document.
+func unmarshalDocument(document any, v any) error {
+	data, err := json.Marshal(jsonCompatible(document))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// jsonCompatible converts the maps decoded from YAML, MessagePack and CBOR,
+// whose keys may be of any type, to values encoding/json can marshal.
+func jsonCompatible(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			v[key] = jsonCompatible(item)
+		}
+
+		return v
+	case map[any]any:
+		converted := make(map[string]any, len(v))
+		for key, item := range v {
+			converted[toString(key)] = jsonCompatible(item)
+		}
+
+		return converted
+	case []any:
+		for i, item := range v {
+			v[i] = jsonCompatible(item)
+		}
+
+		return v
+	default:
+		return v
+	}
+}
+
+// toString formats a YAML mapping key as a JSON object key.
+func toString(key any) string {
+	if s, ok := key.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(key)
+
+	return strings.Trim(string(data), `"`)
+}
+
+// msgpackHandle configures MessagePack to follow the current specification
+// (str8, bin and timestamp types) and to decode into the declared Go types.
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+
+	return h
+}()
+
+// marshalMsgPack encodes v as MessagePack.
+func marshalMsgPack(v any) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, msgpackHandle).Encode(v)
+
+	return data, err
+}
+
+// unmarshalMsgPack decodes MessagePack data into v using v's JSON field names,
+// by converting the document to JSON first.
+func unmarshalMsgPack(data []byte, v any) error {
+	var document any
+	if err := codec.NewDecoderBytes(data, msgpackHandle).Decode(&document); err != nil {
+		return err
+	}
+
+	return unmarshalDocument(document, v)
+}
+
+// cborEncMode encodes timestamps as RFC 3339 strings with tag 0 and sorts map keys
+// canonically, so equal values always encode to the same bytes.
+var cborEncMode = func() cbor.EncMode {
+	mode, err := cbor.EncOptions{
+		Sort:    cbor.SortCanonical,
+		Time:    cbor.TimeRFC3339Nano,
+		TimeTag: cbor.EncTagRequired,
+	}.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return mode
+}()
+
+// unmarshalCBOR decodes CBOR data into v using v's JSON field names, by
+// converting the document to JSON first.
+func unmarshalCBOR(data []byte, v any) error {
+	var document any
+	if err := cbor.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	return unmarshalDocument(document, v)
+}
@@ -0,0 +1,174 @@
+package representation
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testAddress is a nested resource used by the round trip tests.
+type testAddress struct {
+	XMLName xml.Name `json:"-" xml:"address"`
+	City    string   `json:"city" xml:"city"`
+	Country string   `json:"country" xml:"country"`
+}
+
+// testResource exercises the value kinds of the API resources.
+type testResource struct {
+	ID        string      `json:"id" xml:"id"`
+	Name      string      `json:"name" xml:"name"`
+	Active    bool        `json:"active" xml:"active"`
+	Count     int         `json:"count" xml:"count"`
+	Tags      []string    `json:"tags" xml:"tags>tag"`
+	Address   testAddress `json:"address" xml:"address"`
+	CreatedAt time.Time   `json:"created_at" xml:"created_at"`
+}
+
+func TestFormatsRoundTrip(t *testing.T) {
+	want := testResource{
+		ID:        "3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+		Name:      "Ada Lovelace",
+		Active:    true,
+		Count:     42,
+		Tags:      []string{"admin", "beta"},
+		Address:   testAddress{City: "Oslo", Country: "NO"},
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 600, time.UTC),
+	}
+	for _, format := range Formats {
+		t.Run(format.MediaType, func(t *testing.T) {
+			data, err := format.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			var got testResource
+			if err := format.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(want.CreatedAt) {
+				t.Errorf("CreatedAt = %v, want %v", got.CreatedAt, want.CreatedAt)
+			}
+			got.CreatedAt = want.CreatedAt
+			got.Address.XMLName = want.Address.XMLName
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestFormatsUseJSONFieldNames(t *testing.T) {
+	resource := testResource{ID: "1", CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	for _, format := range []Format{YAML, MsgPack, CBOR} {
+		t.Run(format.MediaType, func(t *testing.T) {
+			data, err := format.Marshal(resource)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			for _, key := range []string{"created_at", "address", "city"} {
+				if !strings.Contains(string(data), key) {
+					t.Errorf("encoding lacks the JSON field name %q", key)
+				}
+			}
+			if strings.Contains(string(data), "CreatedAt") {
+				t.Error("encoding uses the Go field name CreatedAt")
+			}
+		})
+	}
+}
+
+func TestMarshalXMLWrapsLists(t *testing.T) {
+	data, err := XML.Marshal([]testAddress{{City: "Oslo"}, {City: "Bergen"}})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<items><address><city>Oslo</city><country></country></address>` +
+		`<address><city>Bergen</city><country></country></address></items>`
+	if string(data) != want {
+		t.Errorf("Marshal() =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestMarshalYAMLQuoting(t *testing.T) {
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{value: testAddress{City: "Oslo", Country: "NO"}, want: "city: Oslo\ncountry: \"NO\"\n"},
+		{value: map[string]any{"a": "true", "b": "1", "c": "yes", "d": "", "e": true}, want: "a: \"true\"\nb: \"1\"\nc: \"yes\"\nd: \"\"\ne: true\n"},
+		{value: []string{"plain", "two words"}, want: "- plain\n- two words\n"},
+	}
+	for _, tt := range tests {
+		data, err := YAML.Marshal(tt.value)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("Marshal(%v) = %q, want %q", tt.value, data, tt.want)
+		}
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+		wantOK      bool
+	}{
+		{contentType: "", want: JSON.MediaType, wantOK: true},
+		{contentType: "application/json; charset=utf-8", want: JSON.MediaType, wantOK: true},
+		{contentType: "TEXT/XML", want: XML.MediaType, wantOK: true},
+		{contentType: "application/x-yaml", want: YAML.MediaType, wantOK: true},
+		{contentType: "application/vnd.msgpack", want: MsgPack.MediaType, wantOK: true},
+		{contentType: "application/cbor", want: CBOR.MediaType, wantOK: true},
+		{contentType: "text/plain"},
+		{contentType: "application/json; charset"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, ok := ForContentType(tt.contentType)
+			if ok != tt.wantOK || got.MediaType != tt.want {
+				t.Errorf("ForContentType() = %q, %v, want %q, %v", got.MediaType, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		accept     string
+		want       string
+		wantParams map[string]string
+		wantOK     bool
+	}{
+		{accept: "", want: JSON.MediaType, wantOK: true},
+		{accept: "*/*", want: JSON.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{accept: "application/xml", want: XML.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{accept: "application/xml;q=0.5, application/cbor", want: CBOR.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{accept: "*/*;q=0.9, application/yaml;q=0.9", want: YAML.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{accept: "application/*", want: JSON.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{accept: "text/*", want: XML.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{accept: "application/json;q=0, */*", want: XML.MediaType, wantParams: map[string]string{}, wantOK: true},
+		{
+			accept:     "application/json; envelope=v1; q=0.8",
+			want:       JSON.MediaType,
+			wantParams: map[string]string{"envelope": "v1"},
+			wantOK:     true,
+		},
+		{accept: "text/html"},
+		{accept: "application/json;q=0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			got, params, ok := Negotiate(tt.accept)
+			if ok != tt.wantOK || got.MediaType != tt.want {
+				t.Errorf("Negotiate() = %q, %v, want %q, %v", got.MediaType, ok, tt.want, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(params, tt.wantParams) {
+				t.Errorf("Negotiate() params = %v, want %v", params, tt.wantParams)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimit returns a gin.HandlerFunc (middleware) that caps the size of request bodies.
+//
+// The limit for a request is looked up in routes by "<METHOD> <route template>"
+// (e.g. "POST /users", which covers "POST /v1/users" and "POST /v2/users" as well),
+// falling back to defaultLimit. Requests declaring a larger
+// Content-Length are rejected up front with HTTP 413 Request Entity Too Large.
+// Bodies without a declared length (chunked) are wrapped in http.MaxBytesReader,
+// so reading past the limit fails with an *http.MaxBytesError, which handlers
+// report as 413 as well.
+//
+// Parameters:
+//   - defaultLimit: The maximum body size in bytes for routes not listed in routes.
+//     Zero or negative disables the default limit.
+//   - routes: Per-route maximum body sizes keyed by "<METHOD> <route template>".
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func BodyLimit(defaultLimit int64, routes map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, ok := routes[routeKey(c)]
+		if !ok {
+			limit = defaultLimit
+		}
+		if limit <= 0 || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			AbortWithError(c, http.StatusRequestEntityTooLarge, BodyTooLargeMessage(limit))
+
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// BodyTooLargeMessage renders the error message returned with HTTP 413 for a body exceeding limit bytes.
+func BodyTooLargeMessage(limit int64) string {
+	return "Request body exceeds the limit of " + formatBytes(limit)
+}
+
+// formatBytes renders a byte count for error messages.
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20 && size%(1<<20) == 0:
+		return strconv.FormatInt(size>>20, 10) + " MiB"
+	case size >= 1<<10 && size%(1<<10) == 0:
+		return strconv.FormatInt(size>>10, 10) + " KiB"
+	default:
+		return strconv.FormatInt(size, 10) + " bytes"
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	engine := gin.New()
+	engine.Use(BodyLimit(16, map[string]int64{"POST /users": 8}))
+	read := func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			AbortWithError(c, http.StatusRequestEntityTooLarge, BodyTooLargeMessage(tooLarge.Limit))
+
+			return
+		}
+		c.String(http.StatusOK, "%d", len(body))
+	}
+	engine.POST("/users", read)
+	engine.POST("/v2/users", read)
+	engine.POST("/other", read)
+
+	tests := []struct {
+		name     string
+		path     string
+		body     string
+		chunked  bool
+		wantCode int
+	}{
+		{name: "within route limit", path: "/users", body: "12345678", wantCode: http.StatusOK},
+		{name: "over route limit", path: "/users", body: "123456789", wantCode: http.StatusRequestEntityTooLarge},
+		{name: "route limit covers versions", path: "/v2/users", body: "123456789", wantCode: http.StatusRequestEntityTooLarge},
+		{name: "default limit", path: "/other", body: "123456789", wantCode: http.StatusOK},
+		{name: "over default limit", path: "/other", body: strings.Repeat("x", 17), wantCode: http.StatusRequestEntityTooLarge},
+		{name: "chunked within limit", path: "/users", body: "1234", chunked: true, wantCode: http.StatusOK},
+		{name: "chunked over limit", path: "/users", body: "123456789", chunked: true, wantCode: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+		})
+	}
+}
+
+func TestBodyTooLargeMessage(t *testing.T) {
+	tests := []struct {
+		limit int64
+		want  string
+	}{
+		{limit: 1 << 20, want: "Request body exceeds the limit of 1 MiB"},
+		{limit: 64 << 10, want: "Request body exceeds the limit of 64 KiB"},
+		{limit: 1000, want: "Request body exceeds the limit of 1000 bytes"},
+	}
+	for _, tt := range tests {
+		if got := BodyTooLargeMessage(tt.limit); got != tt.want {
+			t.Errorf("BodyTooLargeMessage(%d) = %q, want %q", tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported by Compress and Decompress.
+const (
+	EncodingBrotli = "br"
+	EncodingZstd   = "zstd"
+	EncodingGzip   = "gzip"
+)
+
+// incompressibleTypes lists content type prefixes whose payload is already
+// compressed, so compressing them again only costs CPU.
+var incompressibleTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-brotli", "application/octet-stream", "application/pdf",
+}
+
+// encoder is implemented by the gzip, brotli and zstd writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoderPools recycles encoders per content coding, as creating them allocates
+// large buffers.
+var encoderPools = map[string]*sync.Pool{
+	EncodingGzip: {New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}},
+	EncodingBrotli: {New: func() any {
+		return brotli.NewWriterLevel(io.Discard, brotli.DefaultCompression)
+	}},
+	EncodingZstd: {New: func() any {
+		// Only fails on invalid options
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+
+		return w
+	}},
+}
+
+// CompressConfig holds the options of Compress.
+type CompressConfig struct {
+	// Encodings lists the content codings offered, in order of server preference,
+	// among EncodingBrotli, EncodingZstd and EncodingGzip. Unknown codings are ignored.
+	Encodings []string
+	// MinSize is the smallest response body, in bytes, that is compressed. Smaller
+	// bodies are sent as is, as compression would barely reduce or even grow them.
+	MinSize int
+}
+
+// Compress returns a gin.HandlerFunc (middleware) that compresses response bodies
+// with the content coding negotiated from the request's Accept-Encoding header.
+//
+// The coding with the highest q-value among conf.Encodings is used, ties being broken
+// by the order of conf.Encodings; "*" matches any offered coding and q=0 refuses a
+// coding. Without an acceptable coding, the response is sent uncompressed.
+//
+// The body is buffered until conf.MinSize bytes are written, then compressed on the
+// fly. Responses are sent uncompressed when the body stays below conf.MinSize, has no
+// content (HEAD, 204, 304), already has a Content-Encoding, or has a content type
+// that is already compressed (images, video, archives, ...). Every response gets a
+// `Vary: Accept-Encoding` header, so caches keep the variants apart.
+//
+// Parameters:
+//   - conf: The offered codings and minimum size.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Compress(conf CompressConfig) gin.HandlerFunc {
+	var offered []string
+	for _, encoding := range conf.Encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if _, ok := encoderPools[encoding]; ok {
+			offered = append(offered, encoding)
+		}
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), offered)
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: conf.MinSize}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding picks the content coding to use from an Accept-Encoding header
+// value, as described on Compress. It returns "" when none of offered is acceptable.
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+	if acceptEncoding == "" || len(offered) == 0 {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, encoding := range offered {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+		if ok && quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+
+	return best
+}
+
+// compressWriter is a gin.ResponseWriter compressing the body once it reaches minSize.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	// buf holds the body written before the compression decision is made.
+	buf []byte
+	// decided is set once the body is either compressed or passed through.
+	decided bool
+	// encoder compresses the body, or is nil when it is passed through.
+	encoder encoder
+}
+
+// Write implements io.Writer.
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString implements io.StringWriter.
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers immediately, which ends buffering: a response
+// whose headers are sent before its body (e.g. c.AbortWithStatus) is not compressed.
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Written reports whether a response has been started, including a buffered body.
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends the buffered and compressed data to the client.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide chooses between compressing and passing the body through, then writes
+// the buffered body. The body is compressed only if compress is true and the
+// response is eligible.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	header := w.ResponseWriter.Header()
+	status := w.ResponseWriter.Status()
+	if compress && status != http.StatusNoContent && status != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" && !incompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.encoder = encoderPools[w.encoding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// close writes any body still buffered and finishes the compressed stream.
+func (w *compressWriter) close() {
+	if !w.decided {
+		_ = w.decide(false)
+	}
+	if w.encoder == nil {
+		return
+	}
+	_ = w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	encoderPools[w.encoding].Put(w.encoder)
+	w.encoder = nil
+}
+
+// incompressible reports whether contentType is already compressed.
+func incompressible(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
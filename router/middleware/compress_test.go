@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decode decompresses data compressed with the content coding encoding.
+func decode(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		r = gz
+	case EncodingBrotli:
+		r = brotli.NewReader(bytes.NewReader(data))
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("zstd reader: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("decode %s: %v", encoding, err)
+	}
+
+	return decoded
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	offered := []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "gzip", want: EncodingGzip},
+		{acceptEncoding: "gzip, deflate, br, zstd", want: EncodingBrotli},
+		{acceptEncoding: "gzip;q=1.0, br;q=0.5", want: EncodingGzip},
+		{acceptEncoding: "GZIP;Q=0.8, Zstd;q=0.9", want: EncodingZstd},
+		{acceptEncoding: "*", want: EncodingBrotli},
+		{acceptEncoding: "br;q=0, *;q=0.1", want: EncodingZstd},
+		{acceptEncoding: "gzip;q=0", want: ""},
+		{acceptEncoding: "identity, deflate", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if got := negotiateEncoding(tt.acceptEncoding, offered); got != tt.want {
+				t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"first_name":"Ada","last_name":"Lovelace"}`, 100)
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		contentType    string
+		status         int
+		body           string
+		wantEncoding   string
+	}{
+		{name: "gzip", acceptEncoding: "gzip", body: large, wantEncoding: EncodingGzip},
+		{name: "brotli", acceptEncoding: "br", body: large, wantEncoding: EncodingBrotli},
+		{name: "zstd", acceptEncoding: "zstd", body: large, wantEncoding: EncodingZstd},
+		{name: "not accepted", acceptEncoding: "deflate", body: large},
+		{name: "below minimum size", acceptEncoding: "gzip", body: `{"id":"1"}`},
+		{name: "already compressed type", acceptEncoding: "gzip", contentType: "image/png", body: large},
+		{name: "no content", acceptEncoding: "gzip", status: http.StatusNoContent},
+		{name: "HEAD", method: http.MethodHead, acceptEncoding: "gzip", body: large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(Compress(CompressConfig{Encodings: []string{EncodingBrotli, EncodingZstd, EncodingGzip, "deflate"}, MinSize: 1024}))
+			engine.Handle(http.MethodGet, "/", func(c *gin.Context) { respond(c, tt.status, tt.contentType, tt.body) })
+			engine.Handle(http.MethodHead, "/", func(c *gin.Context) { respond(c, tt.status, tt.contentType, tt.body) })
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			body := rec.Body.Bytes()
+			if tt.wantEncoding != "" {
+				body = decode(t, tt.wantEncoding, body)
+			}
+			if method != http.MethodHead && string(body) != tt.body {
+				t.Errorf("body = %.40q..., want %.40q...", body, tt.body)
+			}
+		})
+	}
+}
+
+// respond writes body with status and contentType, defaulting to 200 and JSON.
+func respond(c *gin.Context, status int, contentType, body string) {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if body == "" {
+		c.Status(status)
+
+		return
+	}
+	c.Data(status, contentType, []byte(body))
+}
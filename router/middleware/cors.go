@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig holds the cross-origin resource sharing policy applied by CORS.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API from a browser. Entries are
+	// exact origins ("https://admin.example.com"), wildcard subdomains ("https://*.example.com",
+	// matching any subdomain but not the apex) or "*" for any origin.
+	AllowedOrigins []string
+	// AllowedMethods lists the methods announced to preflight requests.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers announced to preflight requests.
+	// "*" allows any header the browser asks for.
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers browser scripts may read.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies or HTTP authentication. The matching
+	// origin is then always echoed, as browsers reject "*" for credentialed requests.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response. Zero omits the header.
+	MaxAge time.Duration
+	// PathPrefixes restricts the policy to requests whose path starts with one of them.
+	// Empty applies it everywhere.
+	PathPrefixes []string
+}
+
+// CORS returns a gin.HandlerFunc (middleware) implementing the CORS protocol for conf.
+//
+// Requests without an Origin header, or outside conf.PathPrefixes, are passed through
+// unchanged. For a request from an allowed origin, the Access-Control-Allow-Origin
+// (and, if enabled, Access-Control-Allow-Credentials) headers are set. Preflight
+// requests (OPTIONS with Access-Control-Request-Method) are answered directly with
+// HTTP 204 No Content and the allowed methods, headers and max age, or with an
+// HTTP 403 Forbidden problem when the origin is not allowed. Actual requests from
+// origins that are not allowed are served without CORS headers, so browsers
+// refuse to expose the response.
+//
+// It should be registered before middleware that can reject requests, such as
+// RateLimit and BodyLimit, so their error responses are readable by allowed origins.
+//
+// Parameters:
+//   - conf: The CORS policy.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func CORS(conf CORSConfig) gin.HandlerFunc {
+	origins := newOriginMatcher(conf.AllowedOrigins)
+	allowMethods := strings.Join(conf.AllowedMethods, ", ")
+	allowHeaders := strings.Join(conf.AllowedHeaders, ", ")
+	anyHeader := containsFold(conf.AllowedHeaders, "*")
+	exposeHeaders := strings.Join(conf.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(conf.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !hasAnyPrefix(c.Request.URL.Path, conf.PathPrefixes) {
+			c.Next()
+
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if !origins.match(origin) {
+			if preflight {
+				AbortWithError(c, http.StatusForbidden, "Origin "+origin+" is not allowed")
+
+				return
+			}
+			c.Next()
+
+			return
+		}
+
+		if origins.any && !conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if conf.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			if anyHeader {
+				if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+					header.Set("Access-Control-Allow-Headers", requested)
+				}
+			} else if allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+			}
+			if conf.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+
+			return
+		}
+
+		if exposeHeaders != "" {
+			header.Set("Access-Control-Expose-Headers", exposeHeaders)
+		}
+		c.Next()
+	}
+}
+
+// originMatcher matches request origins against the configured allowed origins.
+type originMatcher struct {
+	// any is true when every origin is allowed ("*").
+	any bool
+	// exact holds the lower-cased exact origins.
+	exact map[string]bool
+	// wildcards holds the scheme ("https://") and domain suffix (".example.com") of
+	// wildcard subdomain entries.
+	wildcards [][2]string
+}
+
+// newOriginMatcher compiles the allowed origin entries of a CORSConfig.
+func newOriginMatcher(allowed []string) originMatcher {
+	m := originMatcher{exact: make(map[string]bool)}
+	for _, origin := range allowed {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		switch {
+		case origin == "*":
+			m.any = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(origin, "*")
+			m.wildcards = append(m.wildcards, [2]string{scheme, domain})
+		case origin != "":
+			m.exact[origin] = true
+		}
+	}
+
+	return m
+}
+
+// match reports whether origin is allowed.
+func (m originMatcher) match(origin string) bool {
+	if m.any {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if m.exact[origin] {
+		return true
+	}
+	for _, wildcard := range m.wildcards {
+		scheme, domain := wildcard[0], wildcard[1]
+		if !strings.HasPrefix(origin, scheme) {
+			continue
+		}
+		host := strings.TrimPrefix(origin, scheme)
+		if len(host) > len(domain) && strings.HasSuffix(host, domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasAnyPrefix reports whether path starts with one of prefixes, or prefixes is empty.
+func hasAnyPrefix(path string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
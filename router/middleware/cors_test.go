@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORS(t *testing.T) {
+	conf := CORSConfig{
+		AllowedOrigins: []string{"https://admin.example.com", "https://*.example.org"},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: []string{"Content-Type", "Idempotency-Key"},
+		ExposedHeaders: []string{"X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+		PathPrefixes:   []string{"/users", "/v2/users"},
+	}
+
+	tests := []struct {
+		name        string
+		conf        CORSConfig
+		method      string
+		path        string
+		origin      string
+		headers     map[string]string
+		wantStatus  int
+		wantHeaders map[string]string // "" asserts the header is absent
+	}{
+		{
+			name:       "allowed origin",
+			conf:       conf,
+			method:     http.MethodGet,
+			path:       "/users",
+			origin:     "https://admin.example.com",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":   "https://admin.example.com",
+				"Access-Control-Expose-Headers": "X-Request-ID",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:        "wildcard subdomain",
+			conf:        conf,
+			method:      http.MethodGet,
+			path:        "/v2/users",
+			origin:      "https://eu.app.example.org",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "https://eu.app.example.org"},
+		},
+		{
+			name:        "wildcard does not match the apex",
+			conf:        conf,
+			method:      http.MethodGet,
+			path:        "/users",
+			origin:      "https://example.org",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:        "wildcard does not match another scheme",
+			conf:        conf,
+			method:      http.MethodGet,
+			path:        "/users",
+			origin:      "http://app.example.org",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": ""},
+		},
+		{
+			name:        "outside the path prefixes",
+			conf:        conf,
+			method:      http.MethodGet,
+			path:        "/metrics",
+			origin:      "https://admin.example.com",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""},
+		},
+		{
+			name:       "preflight",
+			conf:       conf,
+			method:     http.MethodOptions,
+			path:       "/users",
+			origin:     "https://admin.example.com",
+			headers:    map[string]string{"Access-Control-Request-Method": http.MethodPost},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":  "https://admin.example.com",
+				"Access-Control-Allow-Methods": "GET, POST",
+				"Access-Control-Allow-Headers": "Content-Type, Idempotency-Key",
+				"Access-Control-Max-Age":       "600",
+			},
+		},
+		{
+			name:        "preflight from a disallowed origin",
+			conf:        conf,
+			method:      http.MethodOptions,
+			path:        "/users",
+			origin:      "https://evil.example.com",
+			headers:     map[string]string{"Access-Control-Request-Method": http.MethodPost},
+			wantStatus:  http.StatusForbidden,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "", "Content-Type": "application/problem+json"},
+		},
+		{
+			name:        "any origin",
+			conf:        CORSConfig{AllowedOrigins: []string{"*"}},
+			method:      http.MethodGet,
+			path:        "/users",
+			origin:      "https://anyone.test",
+			wantStatus:  http.StatusOK,
+			wantHeaders: map[string]string{"Access-Control-Allow-Origin": "*"},
+		},
+		{
+			name:       "any origin with credentials echoes the origin",
+			conf:       CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:     http.MethodGet,
+			path:       "/users",
+			origin:     "https://anyone.test",
+			wantStatus: http.StatusOK,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Origin":      "https://anyone.test",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:       "any requested header",
+			conf:       CORSConfig{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}},
+			method:     http.MethodOptions,
+			path:       "/users",
+			origin:     "https://anyone.test",
+			headers:    map[string]string{"Access-Control-Request-Method": http.MethodPut, "Access-Control-Request-Headers": "x-custom"},
+			wantStatus: http.StatusNoContent,
+			wantHeaders: map[string]string{
+				"Access-Control-Allow-Headers": "x-custom",
+				"Access-Control-Max-Age":       "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(CORS(tt.conf))
+			engine.Any("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			for name, want := range tt.wantHeaders {
+				if got := rec.Header().Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdMaxWindow bounds the memory a zstd request body may make the decoder allocate.
+const zstdMaxWindow = 8 << 20
+
+// Decompress returns a gin.HandlerFunc (middleware) that accepts request bodies
+// compressed with gzip, brotli or zstd, as announced by the Content-Encoding header,
+// and decodes them transparently for the handlers.
+//
+// Bodies with another content coding are rejected with HTTP 415 Unsupported Media
+// Type and an Accept-Encoding response header listing the supported codings.
+// Corrupt bodies fail when read, so handlers report them as invalid requests.
+//
+// It must be registered before BodyLimit, so the size limit applies to the
+// decompressed body and compressed payloads cannot be used to exhaust memory.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Decompress() gin.HandlerFunc {
+	supported := strings.Join([]string{EncodingGzip, EncodingBrotli, EncodingZstd}, ", ")
+
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		if encoding == "" || encoding == "identity" || c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+
+			return
+		}
+
+		body, ok := newDecoder(encoding, c.Request.Body)
+		if !ok {
+			c.Header("Accept-Encoding", supported)
+			AbortWithError(c, http.StatusUnsupportedMediaType, "Content-Encoding "+encoding+" is not supported")
+
+			return
+		}
+
+		c.Request.Body = body
+		c.Request.ContentLength = -1
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Next()
+	}
+}
+
+// newDecoder wraps body in a decoder for encoding, or returns false if the encoding
+// is not supported. Decoders are created on the first Read, as some read the stream
+// header when created, so invalid input surfaces as a read error in the handler.
+func newDecoder(encoding string, body io.ReadCloser) (io.ReadCloser, bool) {
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		return &lazyReader{body: body, open: func(r io.Reader) (io.Reader, func(), error) {
+			decoder, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, nil, fmt.Errorf("decode gzip body: %w", err)
+			}
+
+			return decoder, func() { _ = decoder.Close() }, nil
+		}}, true
+	case EncodingBrotli:
+		return &lazyReader{body: body, open: func(r io.Reader) (io.Reader, func(), error) {
+			return brotli.NewReader(r), func() {}, nil
+		}}, true
+	case EncodingZstd:
+		return &lazyReader{body: body, open: func(r io.Reader) (io.Reader, func(), error) {
+			decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindow))
+			if err != nil {
+				return nil, nil, fmt.Errorf("decode zstd body: %w", err)
+			}
+
+			return decoder, decoder.Close, nil
+		}}, true
+	default:
+		return nil, false
+	}
+}
+
+// lazyReader decodes body with the decoder created by open on the first Read.
+type lazyReader struct {
+	body    io.ReadCloser
+	open    func(io.Reader) (io.Reader, func(), error)
+	decoder io.Reader
+	release func()
+	err     error
+}
+
+// Read implements io.Reader.
+func (r *lazyReader) Read(p []byte) (int, error) {
+	if r.decoder == nil && r.err == nil {
+		r.decoder, r.release, r.err = r.open(r.body)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return r.decoder.Read(p)
+}
+
+// Close implements io.Closer, releasing the decoder and closing the original body.
+func (r *lazyReader) Close() error {
+	if r.release != nil {
+		r.release()
+	}
+
+	return r.body.Close()
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// encode compresses data with the content coding encoding.
+func encode(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case EncodingGzip:
+		w = gzip.NewWriter(&buf)
+	case EncodingBrotli:
+		w = brotli.NewWriter(&buf)
+	case EncodingZstd:
+		var err error
+		if w, err = zstd.NewWriter(&buf); err != nil {
+			t.Fatalf("zstd writer: %v", err)
+		}
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("encode %s: %v", encoding, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("encode %s: %v", encoding, err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	payload := []byte(`{"first_name":"Ada","last_name":"Lovelace"}`)
+
+	tests := []struct {
+		name         string
+		encoding     string
+		body         []byte
+		wantStatus   int
+		wantBody     string
+		wantAccepted string
+	}{
+		{name: "gzip", encoding: "gzip", body: encode(t, EncodingGzip, payload), wantStatus: http.StatusOK, wantBody: string(payload)},
+		{name: "x-gzip", encoding: "x-gzip", body: encode(t, EncodingGzip, payload), wantStatus: http.StatusOK, wantBody: string(payload)},
+		{name: "brotli", encoding: "br", body: encode(t, EncodingBrotli, payload), wantStatus: http.StatusOK, wantBody: string(payload)},
+		{name: "zstd", encoding: "ZSTD", body: encode(t, EncodingZstd, payload), wantStatus: http.StatusOK, wantBody: string(payload)},
+		{name: "identity", encoding: "identity", body: payload, wantStatus: http.StatusOK, wantBody: string(payload)},
+		{name: "corrupt", encoding: "gzip", body: []byte("not gzip"), wantStatus: http.StatusBadRequest},
+		{
+			name:         "unsupported",
+			encoding:     "deflate",
+			body:         payload,
+			wantStatus:   http.StatusUnsupportedMediaType,
+			wantAccepted: "gzip, br, zstd",
+		},
+		{
+			name:       "limit applies to the decompressed body",
+			encoding:   "gzip",
+			body:       encode(t, EncodingGzip, bytes.Repeat([]byte{'a'}, 4096)),
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(Decompress(), BodyLimit(1024, nil))
+			engine.POST("/", func(c *gin.Context) {
+				body, err := io.ReadAll(c.Request.Body)
+				var tooLarge *http.MaxBytesError
+				switch {
+				case errors.As(err, &tooLarge):
+					c.Status(http.StatusRequestEntityTooLarge)
+				case err != nil:
+					c.Status(http.StatusBadRequest)
+				default:
+					c.String(http.StatusOK, "%s", body)
+				}
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+			if got := rec.Header().Get("Accept-Encoding"); got != tt.wantAccepted {
+				t.Errorf("Accept-Encoding = %q, want %q", got, tt.wantAccepted)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecationConfig describes the retirement of an API version.
+type DeprecationConfig struct {
+	// DeprecatedAt is when the version was deprecated, announced in the Deprecation header.
+	DeprecatedAt time.Time
+	// Sunset is when the version stops being served, announced in the Sunset header.
+	// Zero omits the header.
+	Sunset time.Time
+	// Prefix is the path prefix of the deprecated version, e.g. "/v1". It is replaced
+	// with SuccessorPrefix to link to the successor of a resource.
+	Prefix string
+	// SuccessorPrefix is the path prefix of the version replacing it, e.g. "/v2".
+	// Empty omits the successor link.
+	SuccessorPrefix string
+}
+
+// Deprecation returns a gin.HandlerFunc (middleware) announcing that the routes it is
+// registered on are deprecated.
+//
+// Every response carries a Deprecation header (RFC 9745) with conf.DeprecatedAt,
+// a Sunset header (RFC 8594) with conf.Sunset if set, and a Link header pointing
+// to the same resource in the successor version, e.g.
+// `</v2/users/42>; rel="successor-version"` for /v1/users/42.
+//
+// Parameters:
+//   - conf: The deprecation schedule and successor of the version.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Deprecation(conf DeprecationConfig) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(conf.DeprecatedAt.Unix(), 10)
+	sunset := ""
+	if !conf.Sunset.IsZero() {
+		sunset = conf.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("Deprecation", deprecation)
+		if sunset != "" {
+			header.Set("Sunset", sunset)
+		}
+		if conf.SuccessorPrefix != "" {
+			successor := conf.SuccessorPrefix + strings.TrimPrefix(c.Request.URL.Path, conf.Prefix)
+			header.Add("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecation(t *testing.T) {
+	deprecatedAt := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	tests := []struct {
+		name       string
+		conf       DeprecationConfig
+		path       string
+		wantSunset string
+		wantLink   string
+	}{
+		{
+			name:       "versioned path",
+			conf:       DeprecationConfig{DeprecatedAt: deprecatedAt, Sunset: sunset, Prefix: "/v1", SuccessorPrefix: "/v2"},
+			path:       "/v1/users/42",
+			wantSunset: "Thu, 01 Apr 2027 10:00:00 GMT",
+			wantLink:   `</v2/users/42>; rel="successor-version"`,
+		},
+		{
+			name:     "unversioned path",
+			conf:     DeprecationConfig{DeprecatedAt: deprecatedAt, SuccessorPrefix: "/v2"},
+			path:     "/users/42",
+			wantLink: `</v2/users/42>; rel="successor-version"`,
+		},
+		{
+			name: "no successor",
+			conf: DeprecationConfig{DeprecatedAt: deprecatedAt, Prefix: "/v1"},
+			path: "/v1/users/42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(Deprecation(tt.conf))
+			engine.GET("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := rec.Header().Get("Deprecation"); got != "@1792281600" {
+				t.Errorf("Deprecation = %q, want %q", got, "@1792281600")
+			}
+			if got := rec.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("Sunset = %q, want %q", got, tt.wantSunset)
+			}
+			if got := rec.Header().Get("Link"); got != tt.wantLink {
+				t.Errorf("Link = %q, want %q", got, tt.wantLink)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/idempotency"
+)
+
+// IdempotencyKeyHeader is the request header carrying the client's idempotency key.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set to "true" on responses replayed from the idempotency store.
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength bounds the length of accepted idempotency keys.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders lists the response headers stored with an idempotent response.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency returns a gin.HandlerFunc (middleware) that makes a route safe to retry
+// by honouring the Idempotency-Key request header.
+//
+// Requests without the header are passed through unchanged. Otherwise the key is
+// scoped to the route and the caller (see RateLimit for how callers are identified)
+// and the request is fingerprinted by a SHA-256 hash of its body. Then:
+//   - The first request with a key runs normally. Its response is stored for ttl,
+//     unless it is a server error (5xx) or 429, which the client is expected to retry;
+//     the key is then released, as it is when the handler panics.
+//   - A retry with the same key and body gets the stored response replayed, with the
+//     Idempotent-Replayed header set, without running the handler again.
+//   - A retry with the same key but a different body is rejected with HTTP 422
+//     Unprocessable Entity.
+//   - A retry arriving while the first request is still being served is rejected
+//     with HTTP 409 Conflict.
+//
+// If the store fails, the request is served without idempotency protection and a
+// warning is logged. The middleware must run after BodyLimit, as it reads the body.
+//
+// Parameters:
+//   - store: The backend holding idempotency records.
+//   - ttl: How long keys and their responses are remembered.
+//   - apiKeyHeader: The API key header identifying callers, as passed to RateLimit.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Idempotency(store idempotency.Store, ttl time.Duration, apiKeyHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			AbortWithError(c, http.StatusBadRequest, IdempotencyKeyHeader+" must not be longer than 255 characters")
+
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				AbortWithError(c, http.StatusRequestEntityTooLarge, BodyTooLargeMessage(tooLarge.Limit))
+			} else {
+				AbortWithError(c, http.StatusBadRequest, "Failed to read request body")
+			}
+
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(sum[:])
+
+		ctx := c.Request.Context()
+		storeKey := c.Request.Method + " " + c.FullPath() + "|" + clientKey(c, apiKeyHeader) + "|" + key
+		record, err := store.Reserve(ctx, storeKey, fingerprint, ttl)
+		if err != nil {
+			LoggerFrom(c).Warn().Err(err).Msg("Idempotency store unavailable, serving request without idempotency")
+			c.Next()
+
+			return
+		}
+
+		if record != nil {
+			switch {
+			case record.Fingerprint != fingerprint:
+				AbortWithError(c, http.StatusUnprocessableEntity, IdempotencyKeyHeader+" was already used for a request with a different body")
+			case record.Response == nil:
+				AbortWithError(c, http.StatusConflict, "A request with the same "+IdempotencyKeyHeader+" is still being processed")
+			default:
+				replay(c, record.Response)
+			}
+
+			return
+		}
+
+		recorder := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stored := false
+		defer func() {
+			c.Writer = recorder.ResponseWriter
+			// Forget the key of a request that failed or panicked, so it can be retried
+			if !stored {
+				if err := store.Release(ctx, storeKey); err != nil {
+					LoggerFrom(c).Warn().Err(err).Msg("Failed to release idempotency key")
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if !recorder.Written() || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+			return
+		}
+
+		response := idempotency.Response{StatusCode: status, Header: http.Header{}, Body: recorder.body.Bytes()}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				response.Header.Set(name, value)
+			}
+		}
+		if err := store.Complete(ctx, storeKey, response, ttl); err != nil {
+			LoggerFrom(c).Warn().Err(err).Msg("Failed to store idempotent response")
+
+			return
+		}
+		stored = true
+	}
+}
+
+// replay writes a stored response and aborts the request.
+func replay(c *gin.Context, response *idempotency.Response) {
+	for name, values := range response.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(response.StatusCode)
+	_, _ = c.Writer.Write(response.Body)
+	c.Abort()
+}
+
+// recordingWriter is a gin.ResponseWriter keeping a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write implements io.Writer, recording b before writing it to the client.
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString implements io.StringWriter, recording s before writing it to the client.
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
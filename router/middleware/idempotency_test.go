@@ -0,0 +1,201 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/idempotency"
+)
+
+// failingStore is an idempotency.Store that is always unavailable.
+type failingStore struct{}
+
+func (failingStore) Reserve(context.Context, string, string, time.Duration) (*idempotency.Record, error) {
+	return nil, errors.New("store down")
+}
+
+func (failingStore) Complete(context.Context, string, idempotency.Response, time.Duration) error {
+	return errors.New("store down")
+}
+
+func (failingStore) Release(context.Context, string) error {
+	return errors.New("store down")
+}
+
+// idempotentRequest describes one request sent to the idempotent endpoint.
+type idempotentRequest struct {
+	key          string
+	body         string
+	apiKey       string
+	wantStatus   int
+	wantReplayed bool
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int // status answered by the handler
+		requests  []idempotentRequest
+		wantCalls int
+	}{
+		{
+			name:   "replayed",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{key: "k", body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{key: "k", body: `{"a":1}`, wantStatus: http.StatusCreated, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "same key, different body",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{key: "k", body: `{"a":1}`, wantStatus: http.StatusCreated},
+				{key: "k", body: `{"a":2}`, wantStatus: http.StatusUnprocessableEntity},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "keys are scoped to callers",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{key: "k", body: `{}`, apiKey: "one", wantStatus: http.StatusCreated},
+				{key: "k", body: `{}`, apiKey: "two", wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "client errors are replayed",
+			status: http.StatusBadRequest,
+			requests: []idempotentRequest{
+				{key: "k", body: `{}`, wantStatus: http.StatusBadRequest},
+				{key: "k", body: `{}`, wantStatus: http.StatusBadRequest, wantReplayed: true},
+			},
+			wantCalls: 1,
+		},
+		{
+			name:   "server errors are retried",
+			status: http.StatusServiceUnavailable,
+			requests: []idempotentRequest{
+				{key: "k", body: `{}`, wantStatus: http.StatusServiceUnavailable},
+				{key: "k", body: `{}`, wantStatus: http.StatusServiceUnavailable},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "without key",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{body: `{}`, wantStatus: http.StatusCreated},
+				{body: `{}`, wantStatus: http.StatusCreated},
+			},
+			wantCalls: 2,
+		},
+		{
+			name:   "key too long",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: `{}`, wantStatus: http.StatusBadRequest},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			engine := gin.New()
+			engine.POST("/users", Idempotency(idempotency.NewMemoryStore(), time.Hour, "X-Api-Key"), func(c *gin.Context) {
+				calls++
+				c.Header("Location", "/users/1")
+				c.String(tt.status, "call %d", calls)
+			})
+
+			var first string
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(r.body))
+				if r.key != "" {
+					req.Header.Set(IdempotencyKeyHeader, r.key)
+				}
+				if r.apiKey != "" {
+					req.Header.Set("X-Api-Key", r.apiKey)
+				}
+				rec := httptest.NewRecorder()
+				engine.ServeHTTP(rec, req)
+
+				if rec.Code != r.wantStatus {
+					t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, r.wantStatus)
+				}
+				replayed := rec.Header().Get(IdempotentReplayedHeader) == "true"
+				if replayed != r.wantReplayed {
+					t.Errorf("request %d: replayed = %v, want %v", i+1, replayed, r.wantReplayed)
+				}
+				if i == 0 {
+					first = rec.Body.String()
+				} else if replayed && (rec.Body.String() != first || rec.Header().Get("Location") != "/users/1") {
+					t.Errorf("request %d: replayed %q (Location %q), want the original response %q",
+						i+1, rec.Body, rec.Header().Get("Location"), first)
+				}
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyConcurrentRetry(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	engine := gin.New()
+	engine.POST("/users", Idempotency(idempotency.NewMemoryStore(), time.Hour, ""), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	first := make(chan int)
+	go func() { first <- send().Code }()
+	<-started
+	if code := send().Code; code != http.StatusConflict {
+		t.Errorf("retry while in progress: status = %d, want %d", code, http.StatusConflict)
+	}
+	close(release)
+	if code := <-first; code != http.StatusCreated {
+		t.Errorf("first request: status = %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestIdempotencyWithoutStore(t *testing.T) {
+	calls := 0
+	engine := gin.New()
+	engine.POST("/users", Idempotency(failingStore{}, time.Hour, ""), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "k")
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusCreated)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2 when the store is down", calls)
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// identityKey is the gin context key under which the resolved caller identity is stored.
+const identityKey = "identity"
+
+// Identity describes the authenticated caller of a request as resolved by
+// an authentication middleware.
+type Identity struct {
+	// Email is the caller's email address, used to look up the matching models.User.
+	Email string
+}
+
+// SetIdentity stores the resolved caller identity on the gin context so that
+// downstream handlers can retrieve it with IdentityFrom, and adds it to the
+// request-scoped logger (see LoggerFrom) as the "identity" field.
+//
+// Any authentication middleware should call this once it has verified the caller.
+func SetIdentity(c *gin.Context, identity Identity) {
+	c.Set(identityKey, identity)
+
+	requestLogger := LoggerFrom(c).With().Str("identity", identity.Email).Logger()
+	c.Request = c.Request.WithContext(requestLogger.WithContext(c.Request.Context()))
+}
+
+// IdentityFrom returns the caller identity previously stored by SetIdentity.
+// The boolean is false if no authentication middleware resolved an identity
+// for the current request.
+func IdentityFrom(c *gin.Context) (Identity, bool) {
+	value, ok := c.Get(identityKey)
+	if !ok {
+		return Identity{}, false
+	}
+	identity, ok := value.(Identity)
+	if !ok || identity.Email == "" {
+		return Identity{}, false
+	}
+
+	return identity, true
+}
+
+// HeaderIdentity returns a gin.HandlerFunc (middleware) that resolves the caller
+// identity from a trusted request header, such as the
+// "Cf-Access-Authenticated-User-Email" header injected by Cloudflare Access in
+// front of the tunnel.
+//
+// The header is trusted as-is, so this middleware must only be enabled when every
+// request is guaranteed to pass through the authenticating proxy. Requests without
+// the header simply continue without an identity.
+//
+// Parameters:
+//   - header: The name of the request header carrying the caller's email address.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func HeaderIdentity(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if email := strings.TrimSpace(c.GetHeader(header)); email != "" {
+			SetIdentity(c, Identity{Email: email})
+		}
+
+		c.Next()
+	}
+}
@@ -6,8 +6,32 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+)
+
+// LogFormat selects the layout of the request log entries written by StructuredLogger.
+type LogFormat string
+
+const (
+	// LogFormatJSON writes flat JSON fields (method, path, status_code, ...). This is the default.
+	LogFormatJSON LogFormat = "json"
+	// LogFormatGCP writes the `httpRequest` object and trace fields understood by
+	// Google Cloud Logging, so request logs on Cloud Run are rendered and correlated natively.
+	LogFormatGCP LogFormat = "gcp"
 )
 
+// LoggerConfig holds the options of StructuredLoggerWithConfig.
+type LoggerConfig struct {
+	// Format selects the request log layout. Defaults to LogFormatJSON when empty.
+	Format LogFormat
+	// ProjectID is the Google Cloud project ID used to build the
+	// `logging.googleapis.com/trace` resource name in LogFormatGCP.
+	// Trace correlation is skipped when it is empty.
+	ProjectID string
+}
+
 // Logger returns a gin.HandlerFunc (middleware) that logs requests using
 // the global zerolog logger (`log.Logger`).
 //
@@ -21,6 +45,11 @@ func Logger() gin.HandlerFunc {
 	return StructuredLogger(&log.Logger)
 }
 
+// LoggerWithConfig is like Logger but renders request logs according to conf.
+func LoggerWithConfig(conf LoggerConfig) gin.HandlerFunc {
+	return StructuredLoggerWithConfig(&log.Logger, conf)
+}
+
 // StructuredLogger returns a gin.HandlerFunc (middleware) that logs requests
 // using a specific *zerolog.Logger instance provided as input.
 //
@@ -30,15 +59,20 @@ func Logger() gin.HandlerFunc {
 //
 // For each request, it performs the following steps:
 //  1. Records the start time.
-//  2. Calls `c.Next()` to allow downstream handlers to process the request.
-//  3. After downstream processing, records the end time and calculates latency.
-//  4. Gathers request details: Client IP, Method, Path (including query), Status Code, Body Size.
-//  5. Extracts any errors added to the Gin context (`c.Errors`).
-//  6. Determines the log level based on the response Status Code:
+//  2. Derives a request-scoped logger carrying the request's correlation identifiers
+//     ("request_id" and "cf_ray", see RequestID), the OpenTelemetry trace and span IDs
+//     ("trace_id" and "span_id") and the matched route template ("route"),
+//     and stores it in the request's context.Context, where it can be retrieved with
+//     LoggerFrom or zerolog.Ctx. SetIdentity later adds the caller's identity to it.
+//  3. Calls `c.Next()` to allow downstream handlers to process the request.
+//  4. After downstream processing, records the end time and calculates latency.
+//  5. Gathers request details: Client IP, Method, Path (including query), Status Code, Body Size.
+//  6. Extracts any errors added to the Gin context (`c.Errors`).
+//  7. Determines the log level based on the response Status Code:
 //     - >= 500: Error level
 //     - >= 400: Warning level
 //     - < 400: Info level
-//  7. Logs a single structured JSON message including all gathered details using the provided logger instance.
+//  8. Logs a single structured JSON message including all gathered details using the request-scoped logger.
 //     The primary message of the log entry contains Gin's formatted private errors, if any.
 //
 // Parameters:
@@ -47,12 +81,48 @@ func Logger() gin.HandlerFunc {
 // Returns:
 //   - A `gin.HandlerFunc` to be used as middleware.
 func StructuredLogger(logger *zerolog.Logger) gin.HandlerFunc {
+	return StructuredLoggerWithConfig(logger, LoggerConfig{})
+}
+
+// StructuredLoggerWithConfig is like StructuredLogger but renders request logs
+// according to conf.
+//
+// With LogFormatGCP, the request details are logged as a Cloud Logging `httpRequest`
+// object instead of flat fields, and when the request carries an
+// X-Cloud-Trace-Context header the request-scoped logger is enriched with the
+// `logging.googleapis.com/trace`, `spanId` and `trace_sampled` fields, so every log
+// line of the request is grouped under its trace in the Logs Explorer. Without the
+// header, the fields are taken from the OpenTelemetry span of the request, if any.
+func StructuredLoggerWithConfig(logger *zerolog.Logger, conf LoggerConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 
 		start := time.Now() // Start timer
 		path := c.Request.URL.Path
 		raw := c.Request.URL.RawQuery
 
+		// Derive the request-scoped logger so downstream code logs with the correlation IDs
+		ids := correlation.FromContext(c.Request.Context())
+		loggerContext := logger.With()
+		if ids.RequestID != "" {
+			loggerContext = loggerContext.Str("request_id", ids.RequestID)
+		}
+		if ids.RayID != "" {
+			loggerContext = loggerContext.Str("cf_ray", ids.RayID)
+		}
+		spanContext := trace.SpanContextFromContext(c.Request.Context())
+		if spanContext.IsValid() {
+			loggerContext = loggerContext.Str("trace_id", spanContext.TraceID().String()).
+				Str("span_id", spanContext.SpanID().String())
+		}
+		if route := c.FullPath(); route != "" {
+			loggerContext = loggerContext.Str("route", route)
+		}
+		if conf.Format == LogFormatGCP {
+			loggerContext = withCloudTrace(loggerContext, c.GetHeader(cloudTraceHeader), spanContext, conf.ProjectID)
+		}
+		requestLogger := loggerContext.Logger()
+		c.Request = c.Request.WithContext(requestLogger.WithContext(c.Request.Context()))
+
 		// Process request by calling downstream handlers first
 		c.Next()
 
@@ -83,14 +153,22 @@ func StructuredLogger(logger *zerolog.Logger) gin.HandlerFunc {
 		// Determine log level based on status code
 		status := c.Writer.Status() // Get status once for readability and efficiency
 		var logEvent *zerolog.Event
+		// Re-read the logger, downstream middleware may have enriched it (e.g. with the identity)
+		requestLogger = *LoggerFrom(c)
 
 		switch {
 		case status >= 500: // Server errors (5xx)
-			logEvent = logger.Error()
+			logEvent = requestLogger.Error()
 		case status >= 400: // Client errors (4xx) - This case is only reached if status < 500
-			logEvent = logger.Warn()
+			logEvent = requestLogger.Warn()
 		default: // Success, redirects, informational etc. (< 400)
-			logEvent = logger.Info()
+			logEvent = requestLogger.Info()
+		}
+
+		if conf.Format == LogFormatGCP {
+			logEvent.Dict("httpRequest", cloudHTTPRequest(c, param)).Msg(param.ErrorMessage)
+
+			return
 		}
 
 		// Log structured event with relevant fields
@@ -103,3 +181,13 @@ func StructuredLogger(logger *zerolog.Logger) gin.HandlerFunc {
 			Msg(param.ErrorMessage)
 	}
 }
+
+// LoggerFrom returns the request-scoped logger stored in the request's context by
+// StructuredLogger, enriched with the request ID, Ray ID, route and identity.
+//
+// Outside of a request handled by StructuredLogger it falls back to
+// zerolog.DefaultContextLogger. Code that only has a context.Context, such as the
+// service layer, can use zerolog.Ctx(ctx) to the same effect.
+func LoggerFrom(c *gin.Context) *zerolog.Logger {
+	return zerolog.Ctx(c.Request.Context())
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// cloudTraceHeader is the header set by Google front ends (and forwarded by
+// Cloud Run) with the trace context of the request: "TRACE_ID/SPAN_ID;o=OPTIONS".
+const cloudTraceHeader = "X-Cloud-Trace-Context"
+
+// CloudSeverity maps a zerolog level to the LogSeverity names understood by
+// Google Cloud Logging. It is meant to be installed as zerolog.LevelFieldMarshalFunc
+// together with LogFormatGCP.
+func CloudSeverity(level zerolog.Level) string {
+	switch level {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return "DEBUG"
+	case zerolog.InfoLevel:
+		return "INFO"
+	case zerolog.WarnLevel:
+		return "WARNING"
+	case zerolog.ErrorLevel:
+		return "ERROR"
+	case zerolog.FatalLevel:
+		return "CRITICAL"
+	case zerolog.PanicLevel:
+		return "ALERT"
+	default:
+		return "DEFAULT"
+	}
+}
+
+// withCloudTrace adds the Cloud Logging trace correlation fields to the logger context.
+// They are parsed from an X-Cloud-Trace-Context header value when present, and taken
+// from the OpenTelemetry span context otherwise. The context is returned unchanged if
+// neither yields a trace ID, or projectID is empty.
+func withCloudTrace(loggerContext zerolog.Context, header string, spanContext trace.SpanContext, projectID string) zerolog.Context {
+	if projectID == "" {
+		return loggerContext
+	}
+
+	if header == "" {
+		if !spanContext.IsValid() {
+			return loggerContext
+		}
+
+		return loggerContext.Str("logging.googleapis.com/trace", "projects/"+projectID+"/traces/"+spanContext.TraceID().String()).
+			Str("logging.googleapis.com/spanId", spanContext.SpanID().String()).
+			Bool("logging.googleapis.com/trace_sampled", spanContext.IsSampled())
+	}
+
+	traceID, rest, _ := strings.Cut(header, "/")
+	if traceID == "" {
+		return loggerContext
+	}
+	loggerContext = loggerContext.Str("logging.googleapis.com/trace", "projects/"+projectID+"/traces/"+traceID)
+
+	spanPart, options, _ := strings.Cut(rest, ";")
+	// The header carries the span ID in decimal, Cloud Logging expects 16 hex characters
+	if spanID, err := strconv.ParseUint(spanPart, 10, 64); err == nil && spanID != 0 {
+		loggerContext = loggerContext.Str("logging.googleapis.com/spanId", fmt.Sprintf("%016x", spanID))
+	}
+
+	return loggerContext.Bool("logging.googleapis.com/trace_sampled", options == "o=1")
+}
+
+// cloudHTTPRequest renders the request details as a Cloud Logging HttpRequest object.
+// See https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#HttpRequest.
+func cloudHTTPRequest(c *gin.Context, param gin.LogFormatterParams) *zerolog.Event {
+	request := zerolog.Dict().
+		Str("requestMethod", param.Method).
+		Str("requestUrl", param.Path).
+		Int("status", param.StatusCode).
+		Str("responseSize", strconv.Itoa(max(param.BodySize, 0))).
+		Str("userAgent", c.Request.UserAgent()).
+		Str("remoteIp", param.ClientIP).
+		Str("protocol", c.Request.Proto).
+		// Cloud Logging expects a protobuf Duration: seconds with up to nine fractional digits
+		Str("latency", strconv.FormatFloat(param.Latency.Seconds(), 'f', 9, 64)+"s")
+
+	if c.Request.ContentLength > 0 {
+		request = request.Str("requestSize", strconv.FormatInt(c.Request.ContentLength, 10))
+	}
+	if referer := c.Request.Referer(); referer != "" {
+		request = request.Str("referer", referer)
+	}
+
+	return request
+}
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestCloudSeverity(t *testing.T) {
+	tests := []struct {
+		level zerolog.Level
+		want  string
+	}{
+		{level: zerolog.DebugLevel, want: "DEBUG"},
+		{level: zerolog.InfoLevel, want: "INFO"},
+		{level: zerolog.WarnLevel, want: "WARNING"},
+		{level: zerolog.ErrorLevel, want: "ERROR"},
+		{level: zerolog.FatalLevel, want: "CRITICAL"},
+		{level: zerolog.PanicLevel, want: "ALERT"},
+		{level: zerolog.NoLevel, want: "DEFAULT"},
+	}
+	for _, tt := range tests {
+		if got := CloudSeverity(tt.level); got != tt.want {
+			t.Errorf("CloudSeverity(%v) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestWithCloudTrace(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	tests := []struct {
+		name        string
+		header      string
+		spanContext trace.SpanContext
+		projectID   string
+		want        map[string]any // nil: no trace fields
+	}{
+		{
+			name:      "header",
+			header:    "105445aa7843bc8bf206b12000100000/1;o=1",
+			projectID: "demo",
+			want: map[string]any{
+				"logging.googleapis.com/trace":         "projects/demo/traces/105445aa7843bc8bf206b12000100000",
+				"logging.googleapis.com/spanId":        "0000000000000001",
+				"logging.googleapis.com/trace_sampled": true,
+			},
+		},
+		{
+			name:      "header without span or options",
+			header:    "105445aa7843bc8bf206b12000100000",
+			projectID: "demo",
+			want: map[string]any{
+				"logging.googleapis.com/trace":         "projects/demo/traces/105445aa7843bc8bf206b12000100000",
+				"logging.googleapis.com/trace_sampled": false,
+			},
+		},
+		{
+			name:        "OpenTelemetry span",
+			spanContext: spanContext,
+			projectID:   "demo",
+			want: map[string]any{
+				"logging.googleapis.com/trace":         "projects/demo/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+				"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+				"logging.googleapis.com/trace_sampled": true,
+			},
+		},
+		{name: "no project", header: "105445aa7843bc8bf206b12000100000/1;o=1"},
+		{name: "no trace", projectID: "demo"},
+		{name: "empty trace ID", header: "/1;o=1", projectID: "demo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := withCloudTrace(zerolog.New(&buf).With(), tt.header, tt.spanContext, tt.projectID).Logger()
+			logger.Info().Msg("")
+
+			entry := decodeLogLines(t, &buf)[0]
+			delete(entry, "level")
+			if len(entry) != len(tt.want) {
+				t.Errorf("fields = %v, want %v", entry, tt.want)
+			}
+			for field, want := range tt.want {
+				if entry[field] != want {
+					t.Errorf("%s = %v, want %v", field, entry[field], want)
+				}
+			}
+		})
+	}
+}
+
+func TestStructuredLoggerGCPFormat(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	engine := gin.New()
+	engine.Use(StructuredLoggerWithConfig(&logger, LoggerConfig{Format: LogFormatGCP, ProjectID: "demo"}))
+	engine.POST("/users", func(c *gin.Context) { c.String(http.StatusCreated, "created") })
+
+	req := httptest.NewRequest(http.MethodPost, "/users?x=1", bytes.NewBufferString("{}"))
+	req.Header.Set(cloudTraceHeader, "105445aa7843bc8bf206b12000100000/1;o=1")
+	req.Header.Set("User-Agent", "test")
+	req.Header.Set("Referer", "https://example.com/")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := decodeLogLines(t, &buf)[0]
+	if entry["logging.googleapis.com/trace"] != "projects/demo/traces/105445aa7843bc8bf206b12000100000" {
+		t.Errorf("trace = %v", entry["logging.googleapis.com/trace"])
+	}
+	if _, ok := entry["status_code"]; ok {
+		t.Error("flat request fields logged in the GCP format")
+	}
+
+	request, ok := entry["httpRequest"].(map[string]any)
+	if !ok {
+		t.Fatalf("httpRequest = %v, want an object", entry["httpRequest"])
+	}
+	for field, want := range map[string]any{
+		"requestMethod": http.MethodPost,
+		"requestUrl":    "/users?x=1",
+		"status":        float64(http.StatusCreated),
+		"requestSize":   "2",
+		"responseSize":  "7",
+		"userAgent":     "test",
+		"referer":       "https://example.com/",
+		"protocol":      "HTTP/1.1",
+	} {
+		if request[field] != want {
+			t.Errorf("httpRequest.%s = %v, want %v", field, request[field], want)
+		}
+	}
+	if latency, _ := request["latency"].(string); len(latency) < 2 || latency[len(latency)-1] != 's' {
+		t.Errorf("httpRequest.latency = %v, want a protobuf Duration", request["latency"])
+	}
+}
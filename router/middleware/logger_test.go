@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+)
+
+// decodeLogLines decodes the JSON log entries written to buf, one per line.
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var entries []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestStructuredLoggerScopesLoggerToRequest(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	engine := gin.New()
+	engine.Use(RequestID(), StructuredLogger(&logger))
+	engine.GET("/users/:id", func(c *gin.Context) {
+		// Downstream code logs through the context only
+		zerolog.Ctx(c.Request.Context()).Info().Msg("handler")
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42?fields=id", nil)
+	req.Header.Set(correlation.RequestIDHeader, "req-1")
+	req.Header.Set(correlation.RayIDHeader, "8a1b2c3d4e5f6a7b-AMS")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := decodeLogLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("%d log entries, want 2", len(entries))
+	}
+	for i, entry := range entries {
+		for field, want := range map[string]any{
+			"request_id": "req-1",
+			"cf_ray":     "8a1b2c3d4e5f6a7b-AMS",
+			"route":      "/users/:id",
+		} {
+			if entry[field] != want {
+				t.Errorf("entry %d: %s = %v, want %v", i, field, entry[field], want)
+			}
+		}
+	}
+
+	request := entries[1]
+	for field, want := range map[string]any{
+		"level":       "warn",
+		"method":      http.MethodGet,
+		"path":        "/users/42?fields=id",
+		"status_code": float64(http.StatusNotFound),
+	} {
+		if request[field] != want {
+			t.Errorf("request entry: %s = %v, want %v", field, request[field], want)
+		}
+	}
+}
+
+func TestStructuredLoggerLevels(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{status: http.StatusOK, want: "info"},
+		{status: http.StatusFound, want: "info"},
+		{status: http.StatusBadRequest, want: "warn"},
+		{status: http.StatusServiceUnavailable, want: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+			engine := gin.New()
+			engine.Use(StructuredLogger(&logger))
+			engine.GET("/", func(c *gin.Context) { c.Status(tt.status) })
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			entries := decodeLogLines(t, &buf)
+			if got := entries[len(entries)-1]["level"]; got != tt.want {
+				t.Errorf("level = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+// unmatchedRoute is the route label used for requests that matched no route,
+// so that arbitrary paths cannot blow up the label cardinality.
+const unmatchedRoute = "unmatched"
+
+// otherMethod is the method label used for request methods outside of
+// standardMethods, for the same reason.
+const otherMethod = "OTHER"
+
+// standardMethods are the request methods recorded under their own label.
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Metrics returns a gin.HandlerFunc (middleware) that records RED metrics
+// (rate, errors, duration) for every request in the metrics package collectors.
+//
+// Requests are labelled by route template (c.FullPath(), e.g. "/users/:id")
+// rather than the raw path, by method and, once handled, by status code.
+// Methods other than the standard HTTP methods are labelled "OTHER".
+// It should be registered early in the chain so that the recorded duration
+// covers the other middleware.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := methodLabel(c.Request.Method)
+
+		inFlight := metrics.HTTPRequestsInFlight.WithLabelValues(route, method)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.HTTPRequestsTotal.WithLabelValues(route, method, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// methodLabel returns the metric label for the request method: the method itself
+// if it is a standard HTTP method, otherMethod otherwise.
+func methodLabel(method string) string {
+	if !standardMethods[method] {
+		return otherMethod
+	}
+
+	return method
+}
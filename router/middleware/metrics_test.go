@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+func TestMetricsLabels(t *testing.T) {
+	engine := gin.New()
+	engine.HandleMethodNotAllowed = true
+	engine.Use(Metrics())
+	engine.GET("/metrics-test/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		label  string
+		status string
+	}{
+		{name: "route template", method: http.MethodGet, path: "/metrics-test/1", route: "/metrics-test/:id", label: http.MethodGet, status: "200"},
+		{name: "unmatched path", method: http.MethodGet, path: "/metrics-test-missing/1", route: unmatchedRoute, label: http.MethodGet, status: "404"},
+		{name: "standard method", method: http.MethodDelete, path: "/metrics-test/1", route: unmatchedRoute, label: http.MethodDelete, status: "405"},
+		{name: "non-standard method", method: "PROPFIND", path: "/metrics-test/1", route: unmatchedRoute, label: otherMethod, status: "405"},
+		{name: "arbitrary method", method: "X-RANDOM-42", path: "/metrics-test/2", route: unmatchedRoute, label: otherMethod, status: "405"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := metrics.HTTPRequestsTotal.WithLabelValues(tt.route, tt.label, tt.status)
+			before := testutil.ToFloat64(counter)
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if got := testutil.ToFloat64(counter) - before; got != 1 {
+				t.Errorf("requests_total{route=%q,method=%q,status=%q} increased by %v, want 1", tt.route, tt.label, tt.status, got)
+			}
+		})
+	}
+
+	if metrics.HTTPRequestsTotal.DeleteLabelValues(unmatchedRoute, "PROPFIND", "405") {
+		t.Error("non-standard method recorded under its own label")
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/representation"
+)
+
+const (
+	// formatKey is the gin context key holding the negotiated representation.Format.
+	formatKey = "representation_format"
+	// formatParamsKey is the gin context key holding the parameters of the accepted media range.
+	formatParamsKey = "representation_format_params"
+)
+
+// Negotiate returns a gin.HandlerFunc (middleware) that selects the response format
+// from the request's Accept header (see representation.Negotiate) and stores it for
+// FormatFrom, along with the parameters of the accepted media range (see
+// FormatParam). Requests accepting none of the supported formats are rejected with
+// HTTP 406 Not Acceptable. Responses get a `Vary: Accept` header.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Negotiate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept")
+
+		format, params, ok := representation.Negotiate(c.GetHeader("Accept"))
+		if !ok {
+			AbortWithError(c, http.StatusNotAcceptable,
+				"None of the accepted media types is available, supported media types are "+strings.Join(representation.MediaTypes(), ", "))
+
+			return
+		}
+
+		c.Set(formatKey, format)
+		c.Set(formatParamsKey, params)
+		c.Next()
+	}
+}
+
+// FormatFrom returns the response format negotiated by Negotiate, or
+// representation.JSON if the request did not go through Negotiate.
+func FormatFrom(c *gin.Context) representation.Format {
+	if format, ok := c.Get(formatKey); ok {
+		return format.(representation.Format)
+	}
+
+	return representation.JSON
+}
+
+// FormatParam returns the value of the named parameter of the media range that
+// Negotiate accepted, e.g. "v1" for name "envelope" and the Accept header
+// "application/json; envelope=v1". It returns "" if the parameter is absent.
+func FormatParam(c *gin.Context, name string) string {
+	value, _ := c.Get(formatParamsKey)
+	params, _ := value.(map[string]string)
+
+	return params[name]
+}
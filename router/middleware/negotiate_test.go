@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name         string
+		accept       string
+		wantStatus   int
+		wantFormat   string
+		wantEnvelope string
+	}{
+		{name: "default", wantStatus: http.StatusOK, wantFormat: "application/json"},
+		{name: "XML", accept: "text/xml", wantStatus: http.StatusOK, wantFormat: "application/xml"},
+		{name: "parameters", accept: "application/json; envelope=v1", wantStatus: http.StatusOK, wantFormat: "application/json", wantEnvelope: "v1"},
+		{name: "not acceptable", accept: "text/html", wantStatus: http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(Negotiate())
+			engine.GET("/", func(c *gin.Context) {
+				c.Header("X-Format", FormatFrom(c).MediaType)
+				c.Header("X-Envelope", FormatParam(c, "envelope"))
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			if got := rec.Header().Get("X-Format"); got != tt.wantFormat {
+				t.Errorf("format = %q, want %q", got, tt.wantFormat)
+			}
+			if got := rec.Header().Get("X-Envelope"); got != tt.wantEnvelope {
+				t.Errorf("envelope = %q, want %q", got, tt.wantEnvelope)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+)
+
+// AbortWithProblem aborts the request and writes p as an `application/problem+json`
+// response with p.Status as the status code. The problem's Instance and correlation
+// identifiers ("request_id" and, when present, "cf_ray") are filled in from the
+// request, so support can trace the failing call.
+func AbortWithProblem(c *gin.Context, p *problem.Problem) {
+	p.ForRequest(c.Request)
+	// gin only sets the JSON content type when none is set yet
+	c.Header("Content-Type", problem.ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+// AbortWithError is a shorthand for AbortWithProblem with a problem of the
+// default type for status, explained by detail.
+func AbortWithError(c *gin.Context, status int, detail string) {
+	AbortWithProblem(c, problem.New(status, detail))
+}
+
+// NotFound is the engine's NoRoute handler. It answers requests for unknown
+// paths with an HTTP 404 Not Found problem.
+func NotFound(c *gin.Context) {
+	AbortWithError(c, http.StatusNotFound, "No resource exists at "+c.Request.URL.Path)
+}
+
+// MethodNotAllowed is the engine's NoMethod handler, called when the path exists
+// but not for the request method. It answers with an HTTP 405 Method Not Allowed
+// problem; gin sets the Allow header listing the supported methods.
+func MethodNotAllowed(c *gin.Context) {
+	AbortWithError(c, http.StatusMethodNotAllowed, c.Request.Method+" is not supported on "+c.Request.URL.Path)
+}
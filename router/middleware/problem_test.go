@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/problem"
+)
+
+func TestProblemResponses(t *testing.T) {
+	engine := gin.New()
+	engine.HandleMethodNotAllowed = true
+	engine.NoRoute(NotFound)
+	engine.NoMethod(MethodNotAllowed)
+	engine.Use(RequestID())
+	engine.GET("/users", func(c *gin.Context) {
+		AbortWithError(c, http.StatusServiceUnavailable, "user store unavailable")
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantDetail string
+		wantAllow  string
+	}{
+		{
+			name:       "handler error",
+			method:     http.MethodGet,
+			path:       "/users",
+			wantStatus: http.StatusServiceUnavailable,
+			wantDetail: "user store unavailable",
+		},
+		{
+			name:       "unknown path",
+			method:     http.MethodGet,
+			path:       "/nothing",
+			wantStatus: http.StatusNotFound,
+			wantDetail: "No resource exists at /nothing",
+		},
+		{
+			name:       "unsupported method",
+			method:     http.MethodDelete,
+			path:       "/users",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantDetail: "DELETE is not supported on /users",
+			wantAllow:  http.MethodGet,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(correlation.RequestIDHeader, "req-1")
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != problem.ContentType {
+				t.Errorf("Content-Type = %q, want %q", got, problem.ContentType)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+
+			var got problem.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode problem: %v", err)
+			}
+			want := problem.Problem{
+				Type:      problem.DefaultType,
+				Title:     http.StatusText(tt.wantStatus),
+				Status:    tt.wantStatus,
+				Detail:    tt.wantDetail,
+				Instance:  tt.path,
+				RequestID: "req-1",
+			}
+			if got.Type != want.Type || got.Title != want.Title || got.Status != want.Status ||
+				got.Detail != want.Detail || got.Instance != want.Instance || got.RequestID != want.RequestID {
+				t.Errorf("problem = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
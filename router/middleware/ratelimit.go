@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/ratelimit"
+)
+
+// RateLimit returns a gin.HandlerFunc (middleware) that enforces token-bucket
+// rate limits per client and per route.
+//
+// The limit for a request is looked up in routes by "<METHOD> <route template>"
+// (e.g. "POST /users", which covers "POST /v1/users" and "POST /v2/users" as well),
+// falling back to defaultLimit. All versions of a route share the client's bucket. Requests for routes without
+// an enabled limit, and unmatched routes, pass through untouched.
+//
+// Clients are identified, in order of preference, by:
+//  1. The API key in apiKeyHeader (hashed, never stored in clear), if configured.
+//  2. The identity resolved by an authentication middleware (see IdentityFrom).
+//  3. The client IP as resolved by gin (c.ClientIP()), which honours the engine's
+//     TrustedPlatform header (e.g. CF-Connecting-IP) when one is configured, so
+//     that clients behind the tunnel do not all share the connector's address.
+//
+// Every limited response carries RateLimit-Limit, RateLimit-Remaining,
+// RateLimit-Reset and RateLimit-Policy headers. RateLimit-Reset is the time until
+// the bucket is full again. Rejected requests receive HTTP 429 Too Many Requests
+// with a Retry-After header, and RateLimit-Reset equal to it: the time until the
+// next request is allowed.
+//
+// If the store fails (e.g. Redis is unreachable) the request is allowed and a
+// warning is logged, so that the limiter never takes the API down with it.
+//
+// Parameters:
+//   - store: The rate limiting backend holding the token buckets.
+//   - defaultLimit: The limit applied to routes not listed in routes. May be disabled.
+//   - routes: Per-route limits keyed by "<METHOD> <route template>".
+//   - apiKeyHeader: The request header carrying an API key. Only set this when the
+//     key is validated upstream, otherwise clients can pick fresh keys at will.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func RateLimit(store ratelimit.Store, defaultLimit ratelimit.Limit, routes ratelimit.Routes, apiKeyHeader string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := routeKey(c)
+		if route == "" {
+			c.Next()
+
+			return
+		}
+
+		limit, ok := routes[route]
+		if !ok {
+			limit = defaultLimit
+		}
+		if !limit.Enabled() {
+			c.Next()
+
+			return
+		}
+
+		result, err := store.Allow(c.Request.Context(), route+"|"+clientKey(c, apiKeyHeader), limit)
+		if err != nil {
+			LoggerFrom(c).Warn().Err(err).Str("route", route).Msg("Rate limit backend unavailable, allowing request")
+			c.Next()
+
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(ceilSeconds(limit.Period)))
+
+		if !result.Allowed {
+			retryAfter := strconv.Itoa(ceilSeconds(result.RetryAfter))
+			header.Set("RateLimit-Reset", retryAfter)
+			header.Set("Retry-After", retryAfter)
+			AbortWithError(c, http.StatusTooManyRequests, "Rate limit exceeded")
+
+			return
+		}
+		header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		c.Next()
+	}
+}
+
+// clientKey identifies the caller for rate limiting purposes.
+func clientKey(c *gin.Context, apiKeyHeader string) string {
+	if apiKeyHeader != "" {
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			sum := sha256.Sum256([]byte(key))
+
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	if identity, ok := IdentityFrom(c); ok {
+		return "identity:" + strings.ToLower(identity.Email)
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as required by the
+// Retry-After and RateLimit-* headers.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
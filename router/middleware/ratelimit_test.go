@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/ratelimit"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newRateLimitedEngine returns an engine behind Cloudflare enforcing limit on
+// POST /users per client.
+func newRateLimitedEngine(limit ratelimit.Limit, apiKeyHeader string) *gin.Engine {
+	engine := gin.New()
+	_ = engine.SetTrustedProxies(nil)
+	engine.TrustedPlatform = gin.PlatformCloudflare
+	engine.Use(RateLimit(ratelimit.NewMemoryStore(), ratelimit.Limit{}, ratelimit.Routes{"POST /users": limit}, apiKeyHeader))
+	engine.POST("/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	engine.POST("/v2/users", func(c *gin.Context) { c.Status(http.StatusCreated) })
+	engine.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	return engine
+}
+
+func TestRateLimitKeysClients(t *testing.T) {
+	tests := []struct {
+		name         string
+		apiKeyHeader string
+		first        http.Header
+		second       http.Header
+		path         string
+		wantStatus   int
+	}{
+		{
+			name:       "same client through the tunnel",
+			first:      http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}},
+			second:     http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}},
+			path:       "/users",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "different clients through the same connector",
+			first:      http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}},
+			second:     http.Header{"Cf-Connecting-Ip": {"203.0.113.2"}},
+			path:       "/users",
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "versions of a route share the bucket",
+			first:      http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}},
+			second:     http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}},
+			path:       "/v2/users",
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:         "API keys take precedence over the IP",
+			apiKeyHeader: "X-Api-Key",
+			first:        http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}, "X-Api-Key": {"one"}},
+			second:       http.Header{"Cf-Connecting-Ip": {"203.0.113.1"}, "X-Api-Key": {"two"}},
+			path:         "/users",
+			wantStatus:   http.StatusCreated,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRateLimitedEngine(ratelimit.Limit{Requests: 1, Period: time.Minute}, tt.apiKeyHeader)
+			for i, header := range []http.Header{tt.first, tt.second} {
+				path := "/users"
+				if i == 1 {
+					path = tt.path
+				}
+				req := httptest.NewRequest(http.MethodPost, path, nil)
+				req.RemoteAddr = "10.0.0.1:1234" // cloudflared
+				req.Header = header
+				rec := httptest.NewRecorder()
+				engine.ServeHTTP(rec, req)
+
+				want := http.StatusCreated
+				if i == 1 {
+					want = tt.wantStatus
+				}
+				if rec.Code != want {
+					t.Errorf("request %d: status = %d, want %d", i+1, rec.Code, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	// Ten requests a minute, two at a time: a token every six seconds
+	engine := newRateLimitedEngine(ratelimit.Limit{Requests: 10, Period: time.Minute, Burst: 2}, "")
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users", nil)
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	send()
+	allowed := send()
+	for header, want := range map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "12",
+		"RateLimit-Policy":    "10;w=60",
+	} {
+		if got := allowed.Header().Get(header); got != want {
+			t.Errorf("allowed: %s = %q, want %q", header, got, want)
+		}
+	}
+
+	rejected := send()
+	if rejected.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", rejected.Code, http.StatusTooManyRequests)
+	}
+	retryAfter := rejected.Header().Get("Retry-After")
+	if retryAfter != "6" || rejected.Header().Get("RateLimit-Reset") != retryAfter {
+		t.Errorf("rejected: Retry-After = %q, RateLimit-Reset = %q, want both 6",
+			retryAfter, rejected.Header().Get("RateLimit-Reset"))
+	}
+
+	unlimited := httptest.NewRecorder()
+	engine.ServeHTTP(unlimited, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if unlimited.Header().Get("RateLimit-Limit") != "" {
+		t.Error("RateLimit-Limit set on a route without limit")
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/crashreport"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+// Recovery returns a gin.HandlerFunc (middleware) that recovers from panics in
+// downstream handlers, replacing gin.Recovery().
+//
+// For each recovered panic it:
+//  1. Logs the panic value and stack trace ("stack_trace") at error level with the
+//     request-scoped logger (see LoggerFrom), so the entry carries the request's
+//     correlation and trace IDs and is picked up by Cloud Error Reporting.
+//  2. Increments the metrics.HTTPPanicsTotal counter for the route and method.
+//  3. Sends a crashreport.Report to sink, if one is configured.
+//  4. Answers with an HTTP 500 Internal Server Error problem carrying the request
+//     ID, unless the handler had already started the response.
+//
+// Panics caused by the client closing the connection (broken pipe, connection reset)
+// are logged as warnings only, and http.ErrAbortHandler is re-raised so net/http
+// aborts the response as intended.
+//
+// It must be registered after Logger and Metrics, so that the request-scoped logger
+// exists and the 500 response is logged and counted.
+//
+// Parameters:
+//   - sink: The crash report sink, or nil to only log and count panics.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Recovery(sink crashreport.Sink) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			if err, ok := recovered.(error); ok && isBrokenConnection(err) {
+				LoggerFrom(c).Warn().Err(err).Msg("Client connection lost")
+				c.Abort()
+
+				return
+			}
+
+			stack := string(debug.Stack())
+			value := fmt.Sprint(recovered)
+			LoggerFrom(c).Error().Str("panic", value).Str("stack_trace", stack).Msg("Recovered from panic: " + value)
+
+			route := c.FullPath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			metrics.HTTPPanicsTotal.WithLabelValues(route, methodLabel(c.Request.Method)).Inc()
+
+			if sink != nil {
+				ids := correlation.FromContext(c.Request.Context())
+				report := crashreport.Report{
+					Time:      time.Now(),
+					Panic:     value,
+					Stack:     stack,
+					Method:    c.Request.Method,
+					Route:     c.FullPath(),
+					Path:      c.Request.URL.Path,
+					RequestID: ids.RequestID,
+					RayID:     ids.RayID,
+				}
+				if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+					report.TraceID = spanContext.TraceID().String()
+				}
+				sink.Send(report)
+			}
+
+			if c.Writer.Written() {
+				c.Abort()
+
+				return
+			}
+			AbortWithError(c, http.StatusInternalServerError, "")
+		}()
+
+		c.Next()
+	}
+}
+
+// isBrokenConnection reports whether err is caused by the client closing the
+// connection while the response was written.
+func isBrokenConnection(err error) bool {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) {
+		return false
+	}
+	var syscallErr *os.SyscallError
+	if !errors.As(opErr, &syscallErr) {
+		return false
+	}
+	message := strings.ToLower(syscallErr.Error())
+
+	return strings.Contains(message, "broken pipe") || strings.Contains(message, "connection reset by peer")
+}
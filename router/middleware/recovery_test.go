@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/crashreport"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+// recordingSink is a crashreport.Sink keeping the reports it receives.
+type recordingSink struct {
+	reports []crashreport.Report
+}
+
+func (s *recordingSink) Send(report crashreport.Report) {
+	s.reports = append(s.reports, report)
+}
+
+func TestRecovery(t *testing.T) {
+	brokenPipe := &net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)}
+
+	tests := []struct {
+		name        string
+		handler     gin.HandlerFunc
+		wantStatus  int
+		wantReport  bool
+		wantProblem bool
+	}{
+		{
+			name:        "panic",
+			handler:     func(*gin.Context) { panic("boom") },
+			wantStatus:  http.StatusInternalServerError,
+			wantReport:  true,
+			wantProblem: true,
+		},
+		{
+			name: "panic after the response started",
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusAccepted)
+				c.Writer.WriteHeaderNow()
+				panic(errors.New("boom"))
+			},
+			wantStatus: http.StatusAccepted,
+			wantReport: true,
+		},
+		{
+			name:       "client went away",
+			handler:    func(*gin.Context) { panic(brokenPipe) },
+			wantStatus: http.StatusOK, // nothing written
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			engine := gin.New()
+			engine.Use(RequestID(), Recovery(sink))
+			engine.GET("/recovery-test/:id", tt.handler)
+
+			panics := metrics.HTTPPanicsTotal.WithLabelValues("/recovery-test/:id", http.MethodGet)
+			before := testutil.ToFloat64(panics)
+
+			req := httptest.NewRequest(http.MethodGet, "/recovery-test/1", nil)
+			req.Header.Set(correlation.RequestIDHeader, "req-1")
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type") == "application/problem+json"; got != tt.wantProblem {
+				t.Errorf("problem response = %v, want %v", got, tt.wantProblem)
+			}
+
+			counted := testutil.ToFloat64(panics) - before
+			if (counted == 1) != tt.wantReport || (len(sink.reports) == 1) != tt.wantReport {
+				t.Fatalf("counted %v panics and sent %d reports, want reported = %v", counted, len(sink.reports), tt.wantReport)
+			}
+			if !tt.wantReport {
+				return
+			}
+			report := sink.reports[0]
+			if report.Panic != "boom" || report.Route != "/recovery-test/:id" || report.Path != "/recovery-test/1" ||
+				report.Method != http.MethodGet || report.RequestID != "req-1" || report.Stack == "" {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
+
+func TestRecoveryReraisesAbortHandler(t *testing.T) {
+	engine := gin.New()
+	engine.Use(Recovery(nil))
+	engine.GET("/", func(*gin.Context) { panic(http.ErrAbortHandler) })
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler re-raised", recovered)
+		}
+	}()
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+)
+
+// maxCorrelationIDLength bounds the size of client supplied identifiers.
+const maxCorrelationIDLength = 128
+
+// RequestID returns a gin.HandlerFunc (middleware) that establishes the correlation
+// identifiers of a request.
+//
+// It accepts a client supplied X-Request-ID header, or generates a new UUID when the
+// header is missing or malformed, and captures Cloudflare's Cf-Ray header when present.
+// Both identifiers are echoed in the response headers and stored in the request's
+// context.Context (see correlation.FromContext), where StructuredLogger and the error
+// responses pick them up.
+//
+// It should be registered before any other middleware so every log line and error
+// body of the request carries the identifiers.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ids := correlation.IDs{
+			RequestID: c.GetHeader(correlation.RequestIDHeader),
+			RayID:     c.GetHeader(correlation.RayIDHeader),
+		}
+		if !validCorrelationID(ids.RequestID) {
+			ids.RequestID = uuid.NewString()
+		}
+		if !validCorrelationID(ids.RayID) {
+			ids.RayID = ""
+		}
+
+		c.Header(correlation.RequestIDHeader, ids.RequestID)
+		if ids.RayID != "" {
+			c.Header(correlation.RayIDHeader, ids.RayID)
+		}
+
+		c.Request = c.Request.WithContext(correlation.WithIDs(c.Request.Context(), ids))
+
+		c.Next()
+	}
+}
+
+// validCorrelationID reports whether a client supplied identifier is safe to echo
+// and log: non-empty, bounded in length and limited to a conservative character set.
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
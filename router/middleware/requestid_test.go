@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/correlation"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name          string
+		requestID     string
+		rayID         string
+		wantRequestID string // empty: a generated UUID
+		wantRayID     string
+	}{
+		{name: "generated when missing"},
+		{name: "client supplied", requestID: "abc-123_x.y:z", wantRequestID: "abc-123_x.y:z"},
+		{name: "malformed replaced", requestID: "abc 123\n"},
+		{name: "too long replaced", requestID: strings.Repeat("a", maxCorrelationIDLength+1)},
+		{name: "ray ID echoed", rayID: "8a1b2c3d4e5f6a7b-AMS", wantRayID: "8a1b2c3d4e5f6a7b-AMS"},
+		{name: "malformed ray ID dropped", rayID: "<script>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got correlation.IDs
+			engine := gin.New()
+			engine.Use(RequestID())
+			engine.GET("/", func(c *gin.Context) {
+				got = correlation.FromContext(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(correlation.RequestIDHeader, tt.requestID)
+			}
+			if tt.rayID != "" {
+				req.Header.Set(correlation.RayIDHeader, tt.rayID)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			if tt.wantRequestID == "" {
+				if _, err := uuid.Parse(got.RequestID); err != nil {
+					t.Errorf("RequestID = %q, want a generated UUID", got.RequestID)
+				}
+			} else if got.RequestID != tt.wantRequestID {
+				t.Errorf("RequestID = %q, want %q", got.RequestID, tt.wantRequestID)
+			}
+			if got.RayID != tt.wantRayID {
+				t.Errorf("RayID = %q, want %q", got.RayID, tt.wantRayID)
+			}
+			if header := rec.Header().Get(correlation.RequestIDHeader); header != got.RequestID {
+				t.Errorf("%s header = %q, want %q", correlation.RequestIDHeader, header, got.RequestID)
+			}
+			if header := rec.Header().Get(correlation.RayIDHeader); header != tt.wantRayID {
+				t.Errorf("%s header = %q, want %q", correlation.RayIDHeader, header, tt.wantRayID)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// versionPrefix matches the API version prefix of a route template, e.g. "/v2/".
+var versionPrefix = regexp.MustCompile(`^/v[0-9]+/`)
+
+// routeKey returns the "<METHOD> <route template>" key of the request used to look up
+// per-route settings, with any API version prefix removed ("POST /v2/users" becomes
+// "POST /users"), so that the settings of a route apply to all of its versions.
+// It returns "" for requests that matched no route.
+func routeKey(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		return ""
+	}
+
+	return c.Request.Method + " " + versionPrefix.ReplaceAllString(route, "/")
+}
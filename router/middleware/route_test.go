@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRouteKey(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{method: http.MethodPost, path: "/users", want: "POST /users"},
+		{method: http.MethodPost, path: "/v1/users", want: "POST /users"},
+		{method: http.MethodGet, path: "/v2/users/42", want: "GET /users/:id"},
+		{method: http.MethodGet, path: "/v10/users/42", want: "GET /users/:id"},
+		{method: http.MethodGet, path: "/versions/1", want: "GET /versions/:id"},
+		{method: http.MethodGet, path: "/unknown", want: ""},
+	}
+
+	engine := gin.New()
+	var got string
+	record := func(c *gin.Context) { got = routeKey(c) }
+	engine.NoRoute(record)
+	for _, route := range []string{"/users", "/v1/users", "/v2/users/:id", "/v10/users/:id", "/versions/:id"} {
+		engine.GET(route, record)
+		engine.POST(route, record)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			got = "unset"
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+			if got != tt.want {
+				t.Errorf("routeKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
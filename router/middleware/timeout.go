@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeoutMessage is the error message returned with HTTP 504 when a request exceeds its deadline.
+const RequestTimeoutMessage = "The request did not complete in time"
+
+// Timeout returns a gin.HandlerFunc (middleware) that gives each request a deadline.
+//
+// The middleware replaces the request's context.Context with one that is done after
+// timeout, so service calls made with c.Request.Context() stop waiting once the
+// deadline passes and return an error wrapping context.DeadlineExceeded. Handlers
+// are expected to report such errors with AbortWithTimeout.
+//
+// Handlers run on the request goroutine, so the middleware never writes concurrently
+// with them. Once they return, if the deadline was exceeded and nothing was written yet,
+// it responds with HTTP 504 Gateway Timeout. A response already started by a handler
+// is left untouched, so headers are never written twice.
+//
+// The middleware is meant to be attached to route groups with `group.Use()`, so each
+// group can get its own deadline.
+//
+// Parameters:
+//   - timeout: The maximum duration of a request. Zero or negative disables the deadline.
+//
+// Returns:
+//   - A `gin.HandlerFunc` to be used as middleware.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 {
+			c.Next()
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			LoggerFrom(c).Warn().Dur("timeout", timeout).Msg("Request deadline exceeded")
+			AbortWithTimeout(c)
+		}
+	}
+}
+
+// AbortWithTimeout aborts the request with HTTP 504 Gateway Timeout and the standard
+// timeout error body. It is used by Timeout and by handlers whose service call failed
+// because the request deadline was exceeded.
+func AbortWithTimeout(c *gin.Context) {
+	AbortWithError(c, http.StatusGatewayTimeout, RequestTimeoutMessage)
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		handler  gin.HandlerFunc
+		wantCode int
+	}{
+		{
+			name:     "completes in time",
+			timeout:  time.Second,
+			handler:  func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:    "deadline exceeded",
+			timeout: 10 * time.Millisecond,
+			handler: func(c *gin.Context) {
+				<-c.Request.Context().Done()
+			},
+			wantCode: http.StatusGatewayTimeout,
+		},
+		{
+			name:    "response already started",
+			timeout: 10 * time.Millisecond,
+			handler: func(c *gin.Context) {
+				c.Status(http.StatusAccepted)
+				c.Writer.WriteHeaderNow()
+				<-c.Request.Context().Done()
+			},
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:    "disabled",
+			timeout: 0,
+			handler: func(c *gin.Context) {
+				if _, ok := c.Request.Context().Deadline(); ok {
+					c.Status(http.StatusInternalServerError)
+
+					return
+				}
+				c.Status(http.StatusNoContent)
+			},
+			wantCode: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/", Timeout(tt.timeout), tt.handler)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package router
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	"github.com/thoughtgears/cloudflare-tunnels-poc/config"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/crashreport"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/handlers"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/idempotency"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/ratelimit"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/router/middleware"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
 )
@@ -18,54 +22,214 @@ import (
 // config.Debug is false.
 //
 // Middleware added includes:
-//   - A custom structured logger (via middleware.Logger()).
-//   - Gin's default recovery middleware to handle panics gracefully.
+//   - Request ID and Cloudflare Ray ID correlation (via middleware.RequestID()).
+//   - An OpenTelemetry server span per request (via otelgin), continuing any
+//     W3C `traceparent` received from the caller.
+//   - A custom structured logger (via middleware.LoggerWithConfig()), rendering
+//     request logs in the format selected by config.LogFormat.
+//   - Prometheus RED metrics per route template (via middleware.Metrics()).
+//   - Response compression negotiated from Accept-Encoding (via middleware.Compress()),
+//     with the codings in config.CompressionEncodings.
+//   - Panic recovery (via middleware.Recovery()), logging the panic with the
+//     request logger, counting it and sending it to crashReports when set.
+//   - CORS for the user routes of every API version (via middleware.CORS()), answering preflight
+//     requests, when config.CORSAllowedOrigins is set.
+//   - Decompression of gzip, brotli and zstd request bodies (via middleware.Decompress()).
+//   - Request body size limits per route template (via middleware.BodyLimit()),
+//     rejecting oversized bodies with 413.
+//   - A header based identity resolver (via middleware.HeaderIdentity()) when
+//     config.AuthEmailHeader is set.
+//   - Per-client, per-route rate limiting (via middleware.RateLimit()) when a
+//     rate limit store is provided.
+//
+// All error responses are RFC 9457 problem details (`application/problem+json`,
+// see the problem package), including those for unknown paths (404) and
+// unsupported methods (405, with an Allow header).
 //
-// It clears any default trusted proxies using SetTrustedProxies(nil), which is often
-// suitable when running behind a known reverse proxy or load balancer.
+// It clears any default trusted proxies using SetTrustedProxies(nil). When
+// config.ClientIPHeader is set, client IPs are read from that header, set by the
+// edge the tunnel connects to, so clients are told apart even though every request
+// arrives from cloudflared; otherwise the peer address is used.
 //
 // Routes defined:
-//   - GET /health: A simple health check endpoint.
-//   - /users group: CRUD endpoints for user management, handled by the UserHandler.
+//   - GET /livez: Liveness probe, UP as long as the process serves HTTP.
+//   - GET /readyz: Readiness probe running the dependency checks in healthRegistry,
+//     failing with 503 when a check fails or once shutdown has begun.
+//   - GET /health: Alias of /readyz, kept for existing probes.
+//   - GET /metrics: Prometheus metrics, unless config.MetricsPort moves them to a separate listener.
+//   - /v1/users group: CRUD endpoints for user management, handled by the UserHandler,
+//     representing users as models.User. Requests get the group's deadline from
+//     config.RequestTimeoutGroups, or config.RequestTimeout (via middleware.Timeout()), and
+//     responses are encoded as JSON, XML, YAML, MessagePack or CBOR according to the
+//     Accept header (via middleware.Negotiate()).
+//     When config.APIV1DeprecatedAt is set, responses announce the deprecation (a future
+//     date announces an upcoming one) and config.APIV1Sunset (via middleware.Deprecation()).
+//   - /v2/users group: The same endpoints, handled by the UserHandlerV2, representing
+//     users as models.UserV2.
+//   - /users group: The version 1 endpoints under their original, unversioned paths.
+//     When config.APIUnversionedDeprecatedAt (or else config.APIV1DeprecatedAt) is set,
+//     responses announce the deprecation in favour of /v2.
+//
+// The API version is selected by the path prefix only. Media type parameters such as
+// `Accept: application/vnd.example+json; version=2` are not used to negotiate it.
+//
+// The endpoints of each /users group are:
 //   - GET /: Retrieves all users.
-//   - POST /: Creates a new user.
+//   - POST /: Creates a new user. Retries carrying the same Idempotency-Key get
+//     the original response (via middleware.Idempotency()) for config.IdempotencyTTL.
+//   - GET /me: Retrieves the authenticated caller's own user.
+//   - PUT /me: Replaces the caller's own profile fields and preferences.
+//   - PATCH /me: Partially updates the caller's own profile fields and preferences.
 //   - GET /:id: Retrieves a specific user by ID.
 //   - PUT /:id: Updates a specific user by ID.
 //   - DELETE /:id: Deletes a specific user by ID.
 //
 // Parameters:
-//   - config: The application's configuration settings, used here to set the Gin mode.
+//   - config: The application's configuration settings, used here to set the Gin mode,
+//     the service name, the log format, the metrics endpoint, the compression, the CORS policy, the body size limits, the request timeout, the identity header and the rate limits.
 //   - userService: An instance of the UserService, which will be injected into the user handlers.
+//   - rateLimitStore: The backend holding rate limit buckets, or nil to disable rate limiting.
+//   - healthRegistry: The dependency checks and shutdown state reported by the readiness probe.
+//   - crashReports: The sink receiving reports of recovered panics, or nil to disable crash reporting.
 //
 // Returns:
 //   - A pointer to the configured *gin.Engine instance, ready to be run.
-func NewRouter(config config.Config, userService services.UserService) *gin.Engine {
+func NewRouter(config config.Config, userService services.UserService, rateLimitStore ratelimit.Store, healthRegistry *health.Registry, crashReports crashreport.Sink) *gin.Engine {
 	if !config.Debug {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	engine := gin.New()
-	engine.Use(middleware.Logger())
-	engine.Use(gin.Recovery())
+	engine.Use(middleware.RequestID())
+	engine.Use(otelgin.Middleware(config.ServiceName))
+	engine.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Format:    middleware.LogFormat(config.LogFormat),
+		ProjectID: config.GCPProjectID,
+	}))
+	engine.Use(middleware.Metrics())
+	if len(config.CompressionEncodings) > 0 {
+		engine.Use(middleware.Compress(middleware.CompressConfig{
+			Encodings: config.CompressionEncodings,
+			MinSize:   int(config.CompressionMinSize),
+		}))
+	}
+	engine.Use(middleware.Recovery(crashReports))
+	if len(config.CORSAllowedOrigins) > 0 {
+		engine.Use(middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins:   config.CORSAllowedOrigins,
+			AllowedMethods:   config.CORSAllowedMethods,
+			AllowedHeaders:   config.CORSAllowedHeaders,
+			ExposedHeaders:   config.CORSExposedHeaders,
+			AllowCredentials: config.CORSAllowCredentials,
+			MaxAge:           config.CORSMaxAge,
+			PathPrefixes:     []string{"/users", "/v1/users", "/v2/users"},
+		}))
+	}
+	engine.Use(middleware.Decompress())
+	engine.Use(middleware.BodyLimit(int64(config.MaxBodyBytes), config.BodyLimitRoutes))
+
+	if config.AuthEmailHeader != "" {
+		engine.Use(middleware.HeaderIdentity(config.AuthEmailHeader))
+	}
+
+	if rateLimitStore != nil {
+		engine.Use(middleware.RateLimit(rateLimitStore, config.RateLimitDefault, config.RateLimitRoutes, config.RateLimitAPIKeyHeader))
+	}
+
+	// Answer unknown paths and methods with problem details instead of gin's plain text
+	engine.HandleMethodNotAllowed = true
+	engine.NoRoute(middleware.NotFound)
+	engine.NoMethod(middleware.MethodNotAllowed)
 
 	// Explicitly clear trusted proxies (important for security depending on deployment)
-	// If behind a trusted proxy (like Cloudflare), you might configure this differently.
+	// and only take the client IP from the header set by the edge when configured to,
+	// as clients reaching the service directly could send the header themselves.
 	_ = engine.SetTrustedProxies(nil)
+	engine.TrustedPlatform = config.ClientIPHeader
+
+	userHandlerV1 := handlers.NewUserHandler(userService)
+	userHandlerV2 := handlers.NewUserHandlerV2(userService)
+	healthHandler := handlers.NewHealthHandler(healthRegistry)
+
+	var idempotent gin.HandlerFunc
+	if config.IdempotencyTTL > 0 {
+		idempotent = middleware.Idempotency(idempotency.NewMemoryStore(), config.IdempotencyTTL, config.RateLimitAPIKeyHeader)
+	}
+
+	engine.GET("/livez", healthHandler.Livez)   // GET /livez
+	engine.GET("/readyz", healthHandler.Readyz) // GET /readyz
+	engine.GET("/health", healthHandler.Readyz) // GET /health
 
-	userHandler := handlers.NewUserHandler(userService)
+	if config.MetricsPort == "" {
+		engine.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
+	v1Deprecation := middleware.DeprecationConfig{
+		DeprecatedAt:    config.APIV1DeprecatedAt,
+		Sunset:          config.APIV1Sunset,
+		Prefix:          "/v1",
+		SuccessorPrefix: "/v2",
+	}
+
+	v1Routes := engine.Group("/v1/users", userMiddleware(config, "/v1/users")...)
+	if !config.APIV1DeprecatedAt.IsZero() {
+		v1Routes.Use(middleware.Deprecation(v1Deprecation))
+	}
+	registerUserRoutes(v1Routes, userHandlerV1, idempotent)
 
-	engine.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "UP"})
-	})
+	v2Routes := engine.Group("/v2/users", userMiddleware(config, "/v2/users")...)
+	registerUserRoutes(v2Routes, userHandlerV2, idempotent)
 
-	userRoutes := engine.Group("/users")
-	{
-		userRoutes.GET("", userHandler.GetUsers)          // GET /users
-		userRoutes.POST("", userHandler.CreateUser)       // POST /users
-		userRoutes.GET("/:id", userHandler.GetUserByID)   // GET /users/:id
-		userRoutes.PUT("/:id", userHandler.UpdateUser)    // PUT /users/:id
-		userRoutes.DELETE("/:id", userHandler.DeleteUser) // DELETE /users/:id
+	// The unversioned paths predate versioning and serve version 1
+	unversionedDeprecation := v1Deprecation
+	unversionedDeprecation.Prefix = ""
+	if !config.APIUnversionedDeprecatedAt.IsZero() {
+		unversionedDeprecation.DeprecatedAt = config.APIUnversionedDeprecatedAt
+	}
+	unversionedRoutes := engine.Group("/users", userMiddleware(config, "/users")...)
+	if !unversionedDeprecation.DeprecatedAt.IsZero() {
+		unversionedRoutes.Use(middleware.Deprecation(unversionedDeprecation))
 	}
+	registerUserRoutes(unversionedRoutes, userHandlerV1, idempotent)
 
 	return engine
 }
+
+// userHandler is implemented by the handlers of each version of the /users endpoints.
+type userHandler interface {
+	GetUsers(c *gin.Context)
+	CreateUser(c *gin.Context)
+	GetMe(c *gin.Context)
+	UpdateMe(c *gin.Context)
+	PatchMe(c *gin.Context)
+	GetUserByID(c *gin.Context)
+	UpdateUser(c *gin.Context)
+	DeleteUser(c *gin.Context)
+}
+
+// userMiddleware returns the middleware shared by the /users groups of all API versions,
+// with the request deadline configured for group.
+func userMiddleware(config config.Config, group string) []gin.HandlerFunc {
+	return []gin.HandlerFunc{
+		middleware.Timeout(config.RequestTimeoutGroups.For(group, config.RequestTimeout)),
+		middleware.Negotiate(),
+	}
+}
+
+// registerUserRoutes registers the /users endpoints served by handler on group.
+// When idempotent is set, POST requests are deduplicated by it.
+func registerUserRoutes(group *gin.RouterGroup, handler userHandler, idempotent gin.HandlerFunc) {
+	createUser := []gin.HandlerFunc{handler.CreateUser}
+	if idempotent != nil {
+		createUser = append([]gin.HandlerFunc{idempotent}, createUser...)
+	}
+
+	group.GET("", handler.GetUsers)          // GET /users
+	group.POST("", createUser...)            // POST /users
+	group.GET("/me", handler.GetMe)          // GET /users/me
+	group.PUT("/me", handler.UpdateMe)       // PUT /users/me
+	group.PATCH("/me", handler.PatchMe)      // PATCH /users/me
+	group.GET("/:id", handler.GetUserByID)   // GET /users/:id
+	group.PUT("/:id", handler.UpdateUser)    // PUT /users/:id
+	group.DELETE("/:id", handler.DeleteUser) // DELETE /users/:id
+}
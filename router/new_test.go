@@ -0,0 +1,218 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kelseyhightower/envconfig"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/config"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/ratelimit"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/services"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestConfig returns the default configuration, changed by configure.
+func newTestConfig(t *testing.T, configure func(conf *config.Config)) config.Config {
+	t.Helper()
+	var conf config.Config
+	if err := envconfig.Process("", &conf); err != nil {
+		t.Fatalf("load default config: %v", err)
+	}
+	if configure != nil {
+		configure(&conf)
+	}
+
+	return conf
+}
+
+// newTestRouter returns the router of a service configured with the defaults,
+// changed by configure, without rate limits.
+func newTestRouter(t *testing.T, service services.UserService, configure func(conf *config.Config)) *gin.Engine {
+	t.Helper()
+
+	return NewRouter(newTestConfig(t, configure), service, nil, health.NewRegistry(health.NewReadiness(), 0), nil)
+}
+
+func TestNewRouterDeprecation(t *testing.T) {
+	v1DeprecatedAt := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	unversionedDeprecatedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		configure func(conf *config.Config)
+		want      map[string]string // Deprecation header by path
+	}{
+		{
+			name: "not deprecated by default",
+			want: map[string]string{"/users": "", "/v1/users": "", "/v2/users": ""},
+		},
+		{
+			name:      "version 1",
+			configure: func(conf *config.Config) { conf.APIV1DeprecatedAt = v1DeprecatedAt },
+			want:      map[string]string{"/users": "@1792281600", "/v1/users": "@1792281600", "/v2/users": ""},
+		},
+		{
+			name:      "unversioned paths only",
+			configure: func(conf *config.Config) { conf.APIUnversionedDeprecatedAt = unversionedDeprecatedAt },
+			want:      map[string]string{"/users": "@1735689600", "/v1/users": "", "/v2/users": ""},
+		},
+		{
+			name: "unversioned paths earlier than version 1",
+			configure: func(conf *config.Config) {
+				conf.APIV1DeprecatedAt = v1DeprecatedAt
+				conf.APIUnversionedDeprecatedAt = unversionedDeprecatedAt
+			},
+			want: map[string]string{"/users": "@1735689600", "/v1/users": "@1792281600", "/v2/users": ""},
+		},
+	}
+	service := services.NewUserService(nil, "NO")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestRouter(t, service, tt.configure)
+			for path, want := range tt.want {
+				rec := httptest.NewRecorder()
+				engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET %s: status = %d, want %d", path, rec.Code, http.StatusOK)
+				}
+				if got := rec.Header().Get("Deprecation"); got != want {
+					t.Errorf("GET %s: Deprecation = %q, want %q", path, got, want)
+				}
+				wantLink := ""
+				if want != "" {
+					wantLink = `</v2/users>; rel="successor-version"`
+				}
+				if got := rec.Header().Get("Link"); got != wantLink {
+					t.Errorf("GET %s: Link = %q, want %q", path, got, wantLink)
+				}
+			}
+		})
+	}
+}
+
+func TestNewRouterUserShapes(t *testing.T) {
+	service := services.NewUserService(nil, "NO")
+	user, err := service.CreateUser(context.Background(), models.User{
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		Email:     "shapes@example.com",
+		Phone:     "+4722334455",
+		Address:   models.Address{Lines: []string{"Karl Johans gate 1"}, City: "Oslo", PostalCode: "0154", Country: "NO"},
+		Active:    true,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	t.Cleanup(func() { _ = service.DeleteUser(context.Background(), user.ID) })
+	engine := newTestRouter(t, service, nil)
+
+	v1Fields := []string{"active", "address", "created_at", "email", "first_name", "id", "last_name", "phone", "phone_formats", "preferences", "updated_at"}
+	tests := []struct {
+		path       string
+		wantFields []string
+		check      func(t *testing.T, body []byte)
+	}{
+		{
+			path:       "/users/" + user.ID,
+			wantFields: v1Fields,
+		},
+		{
+			path:       "/v1/users/" + user.ID,
+			wantFields: v1Fields,
+			check: func(t *testing.T, body []byte) {
+				var got models.UserV1
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if got.FirstName != "Ada" || got.Address != user.Address.String() || got.Email != user.Email ||
+					got.PhoneFormats.International != "+47 22 33 44 55" {
+					t.Errorf("user = %+v, want the stored user in the version 1 shape", got)
+				}
+			},
+		},
+		{
+			path:       "/v2/users/" + user.ID,
+			wantFields: []string{"address", "contact", "created_at", "id", "name", "notifications", "status", "updated_at"},
+			check: func(t *testing.T, body []byte) {
+				var got models.UserV2
+				if err := json.Unmarshal(body, &got); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				if got.Name.First != "Ada" || got.Contact.Email != user.Email || got.Status != models.UserStatusActive ||
+					got.Address.City != "Oslo" || got.Contact.PhoneFormats.International == "" {
+					t.Errorf("user = %+v, want the stored user in the version 2 shape", got)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+			}
+
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			var names []string
+			for name := range fields {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", names, tt.wantFields)
+			}
+			if tt.check != nil {
+				tt.check(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestNewRouterClientIPHeader(t *testing.T) {
+	tests := []struct {
+		name           string
+		clientIPHeader string
+		wantStatus     int // of the second request, from the same peer with another header value
+	}{
+		{name: "ignored by default", wantStatus: http.StatusTooManyRequests},
+		{name: "trusted when configured", clientIPHeader: "CF-Connecting-IP", wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := newTestConfig(t, func(conf *config.Config) {
+				conf.ClientIPHeader = tt.clientIPHeader
+				conf.RateLimitRoutes = ratelimit.Routes{"GET /users": {Requests: 1, Period: time.Minute}}
+			})
+			engine := NewRouter(conf, services.NewUserService(nil, "NO"), ratelimit.NewMemoryStore(), health.NewRegistry(health.NewReadiness(), 0), nil)
+
+			var status int
+			for _, clientIP := range []string{"198.51.100.1", "198.51.100.2"} {
+				req := httptest.NewRequest(http.MethodGet, "/users", nil)
+				req.RemoteAddr = "10.0.0.1:4321"
+				req.Header.Set("CF-Connecting-IP", clientIP)
+				rec := httptest.NewRecorder()
+				engine.ServeHTTP(rec, req)
+				status = rec.Code
+			}
+			if status != tt.wantStatus {
+				t.Errorf("second client: status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import "fmt"
+
+// Code classifies a service error independently of the transport, so callers can
+// decide how to react (e.g. which HTTP status to answer with) without matching on
+// individual errors.
+type Code string
+
+const (
+	// CodeNotFound means the requested entity does not exist.
+	CodeNotFound Code = "not_found"
+	// CodeConflict means the operation conflicts with the current state of the store,
+	// such as a duplicate email address.
+	CodeConflict Code = "conflict"
+	// CodeValidation means the input was rejected by the service's own rules.
+	CodeValidation Code = "validation"
+	// CodeUnavailable means the store could not be reached; retrying later may succeed.
+	CodeUnavailable Code = "unavailable"
+)
+
+// Error is the error type returned by the UserService. It carries a Code, a message
+// that is safe to show to API clients and, optionally, the underlying cause.
+//
+// Two *Error values match with errors.Is when they have the same Code, so the
+// exported sentinels (ErrUserNotFound, ...) match every error of their class:
+//
+//	errors.Is(err, services.ErrUserNotFound) // true for any CodeNotFound error
+type Error struct {
+	// Code classifies the error.
+	Code Code
+	// Message describes the error for API clients; it never contains the cause.
+	Message string
+	// Err is the underlying cause, if any. It is returned by Unwrap.
+	Err error
+}
+
+// Error implements the error interface, appending the cause to the message.
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap returns the cause of e, so errors.Is and errors.As can inspect it.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an *Error with the same Code as e.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code == e.Code
+}
+
+// ErrUserNotFound matches errors returned when no user matches the request.
+var ErrUserNotFound = &Error{Code: CodeNotFound, Message: "user not found"}
+
+// ErrEmailTaken is returned when a user is created or updated with an email
+// address that already belongs to another user.
+var ErrEmailTaken = &Error{Code: CodeConflict, Message: "email address is already in use by another user"}
+
+// ErrInvalidPhone matches errors returned when a user is created or updated with a
+// phone number that is not valid. Such errors wrap models.ErrInvalidPhone.
+var ErrInvalidPhone = &Error{Code: CodeValidation, Message: "phone number is not valid"}
+
+// ErrStoreUnavailable matches errors returned when the user store cannot be locked
+// before the context is done. Such errors wrap the context error, so callers can
+// tell a deadline (context.DeadlineExceeded) from a cancellation (context.Canceled)
+// with errors.Is.
+var ErrStoreUnavailable = &Error{Code: CodeUnavailable, Message: "user store unavailable"}
+
+// userNotFound returns the CodeNotFound error for the user identified by id.
+func userNotFound(id string) error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf("user with ID '%s' not found", id)}
+}
+
+// storeUnavailable returns the CodeUnavailable error for a store lock that could not
+// be acquired because of cause, the context error.
+func storeUnavailable(cause error) error {
+	return &Error{Code: CodeUnavailable, Message: ErrStoreUnavailable.Message, Err: cause}
+}
+
+// invalidPhone returns the CodeValidation error for a phone number rejected with cause.
+func invalidPhone(cause error) error {
+	return &Error{Code: CodeValidation, Message: ErrInvalidPhone.Message, Err: cause}
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+)
+
+func TestErrorIs(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		target  error
+		matches bool
+	}{
+		{name: "same sentinel", err: ErrUserNotFound, target: ErrUserNotFound, matches: true},
+		{name: "same code", err: userNotFound("42"), target: ErrUserNotFound, matches: true},
+		{name: "other code", err: userNotFound("42"), target: ErrEmailTaken},
+		{name: "cause of unavailable store", err: storeUnavailable(context.DeadlineExceeded), target: context.DeadlineExceeded, matches: true},
+		{name: "unavailable store", err: storeUnavailable(context.Canceled), target: ErrStoreUnavailable, matches: true},
+		{name: "cause of invalid phone", err: invalidPhone(models.ErrInvalidPhone), target: models.ErrInvalidPhone, matches: true},
+		{name: "not a service error", err: errors.New("user not found"), target: ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.matches {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.matches)
+			}
+		})
+	}
+}
+
+func TestErrorMessage(t *testing.T) {
+	if got, want := userNotFound("42").Error(), "user with ID '42' not found"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got, want := storeUnavailable(context.Canceled).Error(), "user store unavailable: context canceled"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
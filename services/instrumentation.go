@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+// tracer creates the spans of the service layer. It resolves the global tracer
+// provider lazily, so spans are recorded once tracing.Setup has run.
+var tracer = otel.Tracer("github.com/thoughtgears/cloudflare-tunnels-poc/services")
+
+// startOperation instruments a UserService call. It starts a span named after the
+// service method ("UserService.<method>") and a child span for the store operation
+// it performs ("userStore.<operation>"), both children of any span in ctx.
+//
+// The returned finish function must be deferred with a pointer to the method's named
+// error result: it marks both spans as failed when an error is returned, ends them,
+// and records the store operation duration in the metrics package.
+//
+// Parameters:
+//   - ctx: The request context, carrying the parent span.
+//   - method: The UserService method name, e.g. "GetUserByID".
+//   - operation: The store operation name, used for the store span and the metrics label.
+//   - attributes: Extra attributes set on the service span, such as the user ID.
+//
+// Returns:
+//   - A context carrying the store span, to be used for the rest of the call.
+//   - The finish function.
+func startOperation(ctx context.Context, method, operation string, attributes ...attribute.KeyValue) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, serviceSpan := tracer.Start(ctx, "UserService."+method, trace.WithAttributes(attributes...))
+	ctx, storeSpan := tracer.Start(ctx, "userStore."+operation, trace.WithAttributes(
+		attribute.String("db.system", "memory"),
+		attribute.String("db.operation.name", operation),
+	))
+
+	return ctx, func(err *error) {
+		for _, span := range []trace.Span{storeSpan, serviceSpan} {
+			if *err != nil {
+				span.RecordError(*err)
+				span.SetStatus(codes.Error, (*err).Error())
+			}
+			span.End()
+		}
+		metrics.ObserveStoreOperation(operation, start, *err)
+	}
+}
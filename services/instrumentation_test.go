@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanRecorder records the spans of the package tracer. The global tracer provider
+// can only be delegated to once, so it is installed once for all tests.
+var spanRecorder = sync.OnceValue(func() *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	return recorder
+})
+
+func TestStartOperationSpans(t *testing.T) {
+	recorder := spanRecorder()
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	service := NewUserService(nil, "US")
+	_, err := service.GetUserByID(ctx, "00000000-0000-0000-0000-000000000000")
+	parent.End()
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetUserByID() error = %v, want ErrUserNotFound", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) < 3 {
+		t.Fatalf("%d spans ended, want 3", len(spans))
+	}
+	store, call := spans[len(spans)-3], spans[len(spans)-2]
+	if store.Name() != "userStore.get_user_by_id" || call.Name() != "UserService.GetUserByID" {
+		t.Errorf("span names = %q, %q, want userStore.get_user_by_id, UserService.GetUserByID", store.Name(), call.Name())
+	}
+	if call.Parent().SpanID() != parent.SpanContext().SpanID() || store.Parent().SpanID() != call.SpanContext().SpanID() {
+		t.Error("spans are not nested request > service > store")
+	}
+	for _, span := range []sdktrace.ReadOnlySpan{store, call} {
+		if span.Status().Code != codes.Error {
+			t.Errorf("%s status = %v, want Error", span.Name(), span.Status().Code)
+		}
+		if len(span.Events()) == 0 || span.Events()[0].Name != "exception" {
+			t.Errorf("%s recorded no error event", span.Name())
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// Weights taken from storeLock. A reader takes one unit, a writer takes all of
+// them, so readers share the store while a writer holds it exclusively.
+const (
+	readLock  int64 = 1
+	writeLock int64 = 1 << 30
+)
+
+// storeLock guards userStore. It is a weighted semaphore used as a readers-writer
+// lock: waiters are served in arrival order, so a writer queued behind readers is
+// not starved by readers arriving after it, and waiting honours the caller's context.
+var storeLock = semaphore.NewWeighted(writeLock)
+
+// lockStore acquires the store lock with weight (readLock or writeLock), waiting
+// in line until the lock is available or ctx is done, so a request whose deadline
+// expires stops waiting for the store instead of blocking.
+//
+// Returns:
+//   - nil once the lock is held; the caller must release it with unlockStore(weight).
+//   - ErrStoreUnavailable, wrapping the context error, if ctx is done first.
+func lockStore(ctx context.Context, weight int64) error {
+	// Acquire may succeed on a done context when the lock is free; a request that
+	// already timed out should not touch the store
+	if err := ctx.Err(); err != nil {
+		return storeUnavailable(err)
+	}
+	if err := storeLock.Acquire(ctx, weight); err != nil {
+		return storeUnavailable(err)
+	}
+
+	return nil
+}
+
+// unlockStore releases the store lock acquired by lockStore with weight.
+func unlockStore(weight int64) {
+	storeLock.Release(weight)
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// acquired starts acquiring the store lock with weight and reports the result on
+// the returned channel.
+func acquired(ctx context.Context, weight int64) <-chan error {
+	result := make(chan error, 1)
+	go func() { result <- lockStore(ctx, weight) }()
+
+	return result
+}
+
+// waitBlocked asserts that the lock attempt reporting on result is still waiting.
+func waitBlocked(t *testing.T, name string, result <-chan error) {
+	t.Helper()
+	select {
+	case err := <-result:
+		t.Fatalf("%s acquired the lock (error %v), want it to wait", name, err)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+// waitAcquired asserts that the lock attempt reporting on result succeeds.
+func waitAcquired(t *testing.T, name string, result <-chan error) {
+	t.Helper()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("%s: lockStore() error = %v", name, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("%s is still waiting for the lock", name)
+	}
+}
+
+func TestStoreLockDoesNotStarveWriters(t *testing.T) {
+	ctx := context.Background()
+	if err := lockStore(ctx, readLock); err != nil {
+		t.Fatalf("lockStore() error = %v", err)
+	}
+
+	writer := acquired(ctx, writeLock)
+	waitBlocked(t, "writer", writer)
+
+	// A reader arriving after the writer queues behind it instead of joining the active reader
+	reader := acquired(ctx, readLock)
+	waitBlocked(t, "late reader", reader)
+
+	unlockStore(readLock)
+	waitAcquired(t, "writer", writer)
+	waitBlocked(t, "late reader", reader)
+
+	unlockStore(writeLock)
+	waitAcquired(t, "late reader", reader)
+	unlockStore(readLock)
+}
+
+func TestStoreLockHonoursContext(t *testing.T) {
+	if err := lockStore(context.Background(), writeLock); err != nil {
+		t.Fatalf("lockStore() error = %v", err)
+	}
+
+	for _, weight := range []int64{readLock, writeLock} {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		err := lockStore(ctx, weight)
+		cancel()
+		if !errors.Is(err, ErrStoreUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("lockStore(%d) on a held lock: error = %v, want ErrStoreUnavailable wrapping the deadline", weight, err)
+		}
+	}
+	unlockStore(writeLock)
+
+	// A request that already timed out does not touch the store, even when it is free
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := lockStore(ctx, readLock); !errors.Is(err, ErrStoreUnavailable) {
+		t.Errorf("lockStore() with a cancelled context: error = %v, want ErrStoreUnavailable", err)
+	}
+}
+
+func TestStoreLockCancelledWriterReleasesQueue(t *testing.T) {
+	if err := lockStore(context.Background(), readLock); err != nil {
+		t.Fatalf("lockStore() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	writer := acquired(ctx, writeLock)
+	waitBlocked(t, "writer", writer)
+	reader := acquired(context.Background(), readLock)
+	waitBlocked(t, "reader", reader)
+
+	// The reader queued behind the writer proceeds once the writer gives up
+	cancel()
+	if err := <-writer; !errors.Is(err, ErrStoreUnavailable) {
+		t.Errorf("cancelled writer: error = %v, want ErrStoreUnavailable", err)
+	}
+	waitAcquired(t, "reader", reader)
+	unlockStore(readLock)
+	unlockStore(readLock)
+}
@@ -1,57 +1,103 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"math/rand"
-	"sync"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-faker/faker/v4"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
 
+	"github.com/thoughtgears/cloudflare-tunnels-poc/geocode"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
 	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
 )
 
-var ErrUserNotFound = errors.New("user not found")
-
 // userStore holds the in-memory list of users.
 var userStore []models.User
 
-// mutex protects concurrent access to userStore.
-var storeMutex sync.RWMutex
-
 // UserService defines the contract for user operations.
+//
+// Every method takes the request's context.Context, which carries the
+// request-scoped logger (see zerolog.Ctx) used to record what the service did
+// and the parent span of the tracing spans created for each call. Methods stop
+// waiting for the store once ctx is done and return ErrStoreUnavailable.
+//
+// Errors returned are *Error values classified by a Code; the documented
+// sentinels match them with errors.Is.
+//
+// The read methods always return complete records; sparse fieldsets are
+// projected by the handlers (see package fieldset).
 type UserService interface {
 	// GetUsers returns all users currently in the store.
-	GetUsers() ([]models.User, error)
+	GetUsers(ctx context.Context) ([]models.User, error)
 	// GetUserByID returns a single user matching the provided ID.
 	// Returns ErrUserNotFound if the user does not exist.
-	GetUserByID(id string) (*models.User, error)
+	GetUserByID(ctx context.Context, id string) (*models.User, error)
+	// GetUserByEmail returns a single user matching the provided email address (case-insensitive).
+	// Returns ErrUserNotFound if the user does not exist.
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	// CreateUser adds a new user to the store.
-	// It assigns a new ID and sets CreatedAt/UpdatedAt timestamps.
-	CreateUser(user models.User) (*models.User, error)
+	// It assigns a new ID, sets CreatedAt/UpdatedAt timestamps, normalises the phone
+	// number to E.164 format and geocodes the address.
+	// Returns ErrInvalidPhone if the phone number is not valid and ErrEmailTaken if
+	// another user already has the email address.
+	CreateUser(ctx context.Context, user models.User) (*models.User, error)
 	// UpdateUser updates an existing user identified by ID.
-	// Only updates specified fields (excluding ID, CreatedAt). Updates UpdatedAt.
-	// Returns ErrUserNotFound if the user does not exist.
-	UpdateUser(id string, updatedData models.User) (*models.User, error)
+	// Only updates specified fields (excluding ID, CreatedAt). Updates UpdatedAt,
+	// normalises the phone number to E.164 format and geocodes the address if it changed.
+	// Returns ErrUserNotFound if the user does not exist, ErrInvalidPhone if the phone
+	// number is not valid and ErrEmailTaken if another user already has the new email address.
+	UpdateUser(ctx context.Context, id string, updatedData models.User) (*models.User, error)
+	// UpdateUserFields changes an existing user identified by ID by applying update to
+	// the stored record, so fields that update leaves alone keep their current values
+	// even if another request changed them concurrently. Updates UpdatedAt and
+	// normalises and geocodes like UpdateUser.
+	// Returns the same errors as UpdateUser.
+	UpdateUserFields(ctx context.Context, id string, update func(user *models.User)) (*models.User, error)
 	// DeleteUser removes a user identified by ID from the store.
 	// Returns ErrUserNotFound if the user does not exist.
-	DeleteUser(id string) error
+	DeleteUser(ctx context.Context, id string) error
+	// Ping verifies that the user store is reachable, i.e. can be read before ctx is done.
+	Ping(ctx context.Context) error
+	// NormalizePhone returns a phone number in E.164 format, the form CreateUser and
+	// UpdateUser store it in, reading numbers without a country calling code as
+	// numbers of the service's default region. Request validation uses it to accept
+	// exactly the numbers the service stores.
+	// Returns ErrInvalidPhone if the phone number is not valid.
+	NormalizePhone(number string) (string, error)
 }
 
 // userServiceImpl provides a concrete implementation of UserService.
 type userServiceImpl struct {
 	// This implementation directly uses the package-level userStore and mutex.
-	// No fields needed here in this specific setup.
 	// If there was need for a repository this is where it should be initialized.
+
+	// geocoder resolves the coordinates of written addresses; nil disables geocoding.
+	geocoder geocode.Geocoder
+	// phoneRegion is the region of phone numbers written without a country calling code.
+	phoneRegion string
 }
 
 // NewUserService creates a new instance of the user service.
 // In this specific setup with package-level storage, this function
-// returns a pointer to a stateless struct that operates on the shared store.
-func NewUserService() UserService {
-	return &userServiceImpl{}
+// returns a pointer to a struct that operates on the shared store.
+//
+// Parameters:
+//   - geocoder: Resolves the coordinates of the addresses of created and updated
+//     users. If nil, addresses are stored without coordinates.
+//   - phoneRegion: The ISO 3166-1 alpha-2 code of the region of phone numbers written
+//     without a country calling code, used to normalise them (see models.NormalizePhone).
+//
+// Returns:
+//   - The UserService.
+func NewUserService(geocoder geocode.Geocoder, phoneRegion string) UserService {
+	return &userServiceImpl{geocoder: geocoder, phoneRegion: phoneRegion}
 }
 
 // init initializes the user service with a random number of fake users.
@@ -63,8 +109,8 @@ func init() {
 	fmt.Printf("Initializing user service with %d users...\n", userCount)
 
 	// Lock the store for initial population
-	storeMutex.Lock()
-	defer storeMutex.Unlock() // Ensure unlock even if panic occurs
+	_ = lockStore(context.Background(), writeLock)
+	defer unlockStore(writeLock) // Ensure unlock even if panic occurs
 
 	// Pre-allocate the slice with the correct size
 	userStore = make([]models.User, userCount)
@@ -87,6 +133,12 @@ func init() {
 		tempUser.CreatedAt = now       // Set creation timestamp
 		tempUser.UpdatedAt = now       // Set updated timestamp
 
+		// Use a valid phone number, so that it can be stored in E.164 format
+		tempUser.Phone = fakePhone()
+
+		// Use a real address, so that it can be structured and has coordinates
+		tempUser.Address = seedAddress(faker.GetRealAddress())
+
 		// Manually set booleans (overriding any potential faker value if needed, or just setting)
 		tempUser.Active = rand.Intn(2) == 1            //nolint:gosec // G404: Non-sensitive fake active status
 		tempUser.Preferences.Email = rand.Intn(2) == 1 //nolint:gosec // G404: Non-sensitive fake preference
@@ -95,22 +147,45 @@ func init() {
 		// --- Assign the fully populated tempUser to the slice index ---
 		userStore[i] = tempUser // Assign the complete struct to the slice index i
 	}
+	metrics.StoreUsers.Set(float64(len(userStore)))
 	fmt.Println("User service initialized.")
 }
 
+// seedAddress returns the address of a seeded user living at address. It is
+// migrated like the addresses stored before they were structured: written as a
+// single line, e.g. "1 Main St, Springfield, IL 62701, USA", and parsed with
+// models.ParseAddress.
+func seedAddress(address faker.RealAddress) models.Address {
+	legacy := fmt.Sprintf("%s, %s, %s %s, USA", address.Address, address.City, address.State, address.PostalCode)
+	migrated := models.ParseAddress(legacy)
+	migrated.Coordinates = &models.Coordinates{
+		Latitude:  address.Coordinates.Latitude,
+		Longitude: address.Coordinates.Longitude,
+	}
+
+	return migrated
+}
+
 // GetUsers retrieves all users currently stored in memory.
 //
 // It returns a slice containing copies of the user data. Modifications to the
 // returned slice or its elements will not affect the internal user store.
 // This function is safe for concurrent use.
 //
+// The ctx parameter carries the request-scoped logger and the parent span.
+//
 // Currently, it always returns a nil error, but the signature allows for
 // future error handling.
-func (s *userServiceImpl) GetUsers() ([]models.User, error) {
-	storeMutex.RLock() // Lock for reading
-	defer storeMutex.RUnlock()
+func (s *userServiceImpl) GetUsers(ctx context.Context) (users []models.User, err error) {
+	ctx, finish := startOperation(ctx, "GetUsers", "get_users")
+	defer finish(&err)
+	if err = lockStore(ctx, readLock); err != nil {
+		return nil, err
+	}
+	defer unlockStore(readLock)
 	usersCopy := make([]models.User, len(userStore))
 	copy(usersCopy, userStore)
+	zerolog.Ctx(ctx).Debug().Int("user_count", len(usersCopy)).Msg("Listed users")
 
 	return usersCopy, nil
 }
@@ -118,19 +193,34 @@ func (s *userServiceImpl) GetUsers() ([]models.User, error) {
 // GetUserByID searches for and returns a single user based on their unique ID.
 //
 // Parameters:
+//   - ctx: The request context, carrying the request-scoped logger and parent span.
 //   - id: The UUID string of the user to retrieve.
 //
 // Returns:
 //   - A pointer to a copy of the found models.User struct if a user with the
 //     specified ID exists. Modifications to the returned user will not affect
 //     the internal user store.
-//   - nil and ErrUserNotFound if no user matches the provided ID.
-//   - nil and potentially other errors in the future (currently only returns ErrUserNotFound on failure).
+//   - nil and an error matching ErrUserNotFound if no user matches the provided ID.
+//   - nil and an error matching ErrStoreUnavailable if ctx is done before the store could be read.
 //
 // This function is safe for concurrent use.
-func (s *userServiceImpl) GetUserByID(id string) (*models.User, error) {
-	storeMutex.RLock() // Lock for reading
-	defer storeMutex.RUnlock()
+func (s *userServiceImpl) GetUserByID(ctx context.Context, id string) (user *models.User, err error) {
+	ctx, finish := startOperation(ctx, "GetUserByID", "get_user_by_id", attribute.String("user.id", id))
+	defer finish(&err)
+
+	return findUser(ctx, id)
+}
+
+// findUser returns a copy of the stored user with the given id, or an error
+// matching ErrUserNotFound.
+// The other methods of the service look users up through it rather than through
+// GetUserByID, so that their lookups are not traced and counted as get_user_by_id
+// operations.
+func findUser(ctx context.Context, id string) (*models.User, error) {
+	if err := lockStore(ctx, readLock); err != nil {
+		return nil, err
+	}
+	defer unlockStore(readLock)
 	for i := range userStore {
 		if userStore[i].ID == id {
 			userCopy := userStore[i]
@@ -138,6 +228,40 @@ func (s *userServiceImpl) GetUserByID(id string) (*models.User, error) {
 			return &userCopy, nil
 		}
 	}
+	zerolog.Ctx(ctx).Debug().Str("user_id", id).Msg("User not found")
+
+	return nil, userNotFound(id)
+}
+
+// GetUserByEmail searches for and returns a single user based on their email address.
+// The comparison is case-insensitive, as email addresses are matched the same way
+// by identity providers.
+//
+// Parameters:
+//   - ctx: The request context, carrying the request-scoped logger and parent span.
+//   - email: The email address of the user to retrieve.
+//
+// Returns:
+//   - A pointer to a copy of the found models.User struct if a user with the
+//     specified email exists.
+//   - nil and ErrUserNotFound if no user matches the provided email.
+//
+// This function is safe for concurrent use.
+func (s *userServiceImpl) GetUserByEmail(ctx context.Context, email string) (user *models.User, err error) {
+	ctx, finish := startOperation(ctx, "GetUserByEmail", "get_user_by_email")
+	defer finish(&err)
+	if err = lockStore(ctx, readLock); err != nil {
+		return nil, err
+	}
+	defer unlockStore(readLock)
+	for i := range userStore {
+		if strings.EqualFold(userStore[i].Email, email) {
+			userCopy := userStore[i]
+
+			return &userCopy, nil
+		}
+	}
+	zerolog.Ctx(ctx).Debug().Msg("No user found for email")
 
 	return nil, ErrUserNotFound
 }
@@ -151,24 +275,47 @@ func (s *userServiceImpl) GetUserByID(id string) (*models.User, error) {
 // The new user is appended to the internal user store.
 //
 // Parameters:
+//   - ctx: The request context, carrying the request-scoped logger and parent span.
 //   - user: A models.User struct containing the desired data for the new user.
-//     ID, CreatedAt, and UpdatedAt fields will be overwritten.
+//     ID, CreatedAt, UpdatedAt and Address.Coordinates fields will be overwritten.
+//
+// The phone number is normalised to E.164 format, reading numbers without a
+// country calling code as numbers of the service's phoneRegion.
+// The address is geocoded before the store is locked. If it cannot be geocoded
+// the user is created without coordinates, and the failure is logged.
 //
 // Returns:
 //   - A pointer to a copy of the newly created user struct, including the
-//     assigned ID and timestamps.
-//   - A nil error (currently no specific error conditions are handled during creation).
+//     assigned ID, timestamps and coordinates.
+//   - nil and an error matching ErrInvalidPhone if the phone number is not valid.
+//   - nil and ErrEmailTaken if another user already has the email address (case-insensitive).
+//   - nil and an error matching ErrStoreUnavailable if ctx is done before the store could be locked.
 //
 // This function is safe for concurrent use.
-func (s *userServiceImpl) CreateUser(user models.User) (*models.User, error) {
-	storeMutex.Lock() // Lock for writing
-	defer storeMutex.Unlock()
+func (s *userServiceImpl) CreateUser(ctx context.Context, user models.User) (created *models.User, err error) {
+	ctx, finish := startOperation(ctx, "CreateUser", "create_user")
+	defer finish(&err)
+	if user.Phone, err = s.NormalizePhone(user.Phone); err != nil {
+		return nil, err
+	}
+	user.Address.Coordinates = s.geocode(ctx, user.Address)
+	if err = lockStore(ctx, writeLock); err != nil {
+		return nil, err
+	}
+	defer unlockStore(writeLock)
+	if emailTaken(user.Email, "") {
+		zerolog.Ctx(ctx).Debug().Msg("Email address already in use")
+
+		return nil, ErrEmailTaken
+	}
 	now := time.Now()
 	user.ID = uuid.NewString()
 	user.CreatedAt = now
 	user.UpdatedAt = now
 	userStore = append(userStore, user)
+	metrics.StoreUsers.Set(float64(len(userStore)))
 	createdUserCopy := user
+	zerolog.Ctx(ctx).Info().Str("user_id", user.ID).Msg("User created")
 
 	return &createdUserCopy, nil
 }
@@ -178,63 +325,155 @@ func (s *userServiceImpl) CreateUser(user models.User) (*models.User, error) {
 //
 // It searches for the user matching the given ID. If found, it updates the
 // user's fields (FirstName, LastName, Email, Phone, Address, Active, Preferences)
-// in the internal store with the values from the updatedData parameter.
-// The user's UpdatedAt field is set to the current time (formatted as a string).
-// The user's ID and CreatedAt fields remain unchanged.
+// in the internal store with the values from the updatedData parameter, as
+// UpdateUserFields does.
 //
 // Parameters:
+//   - ctx: The request context, carrying the request-scoped logger and parent span.
 //   - id: The UUID string of the user to update.
 //   - updatedData: A models.User struct containing the new data for the user.
-//     ID and CreatedAt fields from this parameter are ignored.
+//     ID, CreatedAt, UpdatedAt and Revision fields and Address.Coordinates from this
+//     parameter are ignored.
+//
+// Returns:
+//   - The same results as UpdateUserFields.
+//
+// This function is safe for concurrent use.
+func (s *userServiceImpl) UpdateUser(ctx context.Context, id string, updatedData models.User) (updated *models.User, err error) {
+	ctx, finish := startOperation(ctx, "UpdateUser", "update_user", attribute.String("user.id", id))
+	defer finish(&err)
+
+	return s.updateUser(ctx, id, func(user *models.User) {
+		user.FirstName = updatedData.FirstName
+		user.LastName = updatedData.LastName
+		user.Email = updatedData.Email
+		user.Phone = updatedData.Phone
+		user.Address = updatedData.Address
+		user.Active = updatedData.Active
+		user.Preferences = updatedData.Preferences
+	})
+}
+
+// UpdateUserFields finds a user by ID and changes the fields set by update.
+//
+// update is applied to a copy of the stored user, and the result is stored only
+// if the user was not changed in the meantime, i.e. its Revision is unchanged;
+// otherwise update is applied again to the new version. Concurrent changes to
+// fields update does not set are therefore never overwritten. update may be
+// called more than once and must only set fields of the user it is given;
+// changes to ID, CreatedAt, UpdatedAt and Revision are ignored.
+//
+// The phone number is normalised to E.164 format as by CreateUser. If the
+// address differs from the stored one (see models.Address.SameLocation) it is
+// geocoded before the store is locked for writing; otherwise the stored
+// coordinates are kept. Coordinates set by update are ignored.
+//
+// Parameters:
+//   - ctx: The request context, carrying the request-scoped logger and parent span.
+//   - id: The UUID string of the user to update.
+//   - update: Sets the new values of the fields to change.
 //
 // Returns:
 //   - A pointer to a copy of the updated models.User struct as it exists in the store
 //     after the update, including the new UpdatedAt timestamp.
-//   - nil and ErrUserNotFound if no user matches the provided ID.
-//   - nil and potentially other errors in the future.
+//   - nil and an error matching ErrUserNotFound if no user matches the provided ID.
+//   - nil and an error matching ErrInvalidPhone if the phone number is not valid.
+//   - nil and ErrEmailTaken if another user already has the new email address (case-insensitive).
+//   - nil and an error matching ErrStoreUnavailable if ctx is done before the store could be locked.
 //
 // This function is safe for concurrent use.
-func (s *userServiceImpl) UpdateUser(id string, updatedData models.User) (*models.User, error) {
-	storeMutex.Lock() // Lock for writing
-	defer storeMutex.Unlock()
+func (s *userServiceImpl) UpdateUserFields(ctx context.Context, id string, update func(user *models.User)) (updated *models.User, err error) {
+	ctx, finish := startOperation(ctx, "UpdateUserFields", "update_user", attribute.String("user.id", id))
+	defer finish(&err)
+
+	return s.updateUser(ctx, id, update)
+}
+
+// updateUser implements UpdateUserFields. The new version of the user is prepared
+// without holding the write lock, so that geocoding does not block other requests,
+// and stored if the user's Revision still matches the version it was derived from.
+func (s *userServiceImpl) updateUser(ctx context.Context, id string, update func(user *models.User)) (*models.User, error) {
+	for {
+		current, err := findUser(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		next := *current
+		next.Address.Lines = slices.Clone(current.Address.Lines)
+		update(&next)
+		next.ID, next.CreatedAt = current.ID, current.CreatedAt
+		if next.Phone, err = s.NormalizePhone(next.Phone); err != nil {
+			return nil, err
+		}
+		if next.Address.SameLocation(current.Address) {
+			next.Address.Coordinates = current.Address.Coordinates
+		} else {
+			next.Address.Coordinates = s.geocode(ctx, next.Address)
+		}
+
+		stored, retry, err := storeUpdate(ctx, current.Revision, next)
+		if !retry {
+			return stored, err
+		}
+		zerolog.Ctx(ctx).Debug().Str("user_id", id).Msg("User changed concurrently, retrying update")
+	}
+}
+
+// storeUpdate replaces the stored version of user, identified by user.ID, with
+// user if the stored Revision equals base. It reports retry if the stored user
+// has changed since base; the update must then be derived from the new version.
+func storeUpdate(ctx context.Context, base uint64, user models.User) (stored *models.User, retry bool, err error) {
+	if err = lockStore(ctx, writeLock); err != nil {
+		return nil, false, err
+	}
+	defer unlockStore(writeLock)
 	foundIndex := -1
 	for i := range userStore {
-		if userStore[i].ID == id {
+		if userStore[i].ID == user.ID {
 			foundIndex = i
 
 			break
 		}
 	}
 	if foundIndex == -1 {
-		return nil, ErrUserNotFound
+		zerolog.Ctx(ctx).Debug().Str("user_id", user.ID).Msg("User to update not found")
+
+		return nil, false, userNotFound(user.ID)
+	}
+	if userStore[foundIndex].Revision != base {
+		return nil, true, nil
 	}
-	originalUser := &userStore[foundIndex]
-	originalUser.FirstName = updatedData.FirstName
-	originalUser.LastName = updatedData.LastName
-	originalUser.Email = updatedData.Email
-	originalUser.Phone = updatedData.Phone
-	originalUser.Address = updatedData.Address
-	originalUser.Active = updatedData.Active
-	originalUser.Preferences = updatedData.Preferences
-	originalUser.UpdatedAt = time.Now()
-	updatedUserCopy := *originalUser
+	if emailTaken(user.Email, user.ID) {
+		zerolog.Ctx(ctx).Debug().Str("user_id", user.ID).Msg("Email address already in use")
 
-	return &updatedUserCopy, nil
+		return nil, false, ErrEmailTaken
+	}
+	user.Revision = base + 1
+	user.UpdatedAt = time.Now()
+	userStore[foundIndex] = user
+	zerolog.Ctx(ctx).Info().Str("user_id", user.ID).Msg("User updated")
+
+	return &user, false, nil
 }
 
 // DeleteUser removes a user from the in-memory store based on their unique ID.
 //
 // Parameters:
+//   - ctx: The request context, carrying the request-scoped logger and parent span.
 //   - id: The UUID string of the user to delete.
 //
 // Returns:
 //   - nil if the user was successfully found and removed.
-//   - ErrUserNotFound if no user matches the provided ID.
+//   - An error matching ErrUserNotFound if no user matches the provided ID.
 //
 // This function modifies the internal user store and is safe for concurrent use.
-func (s *userServiceImpl) DeleteUser(id string) error {
-	storeMutex.Lock() // Lock for writing
-	defer storeMutex.Unlock()
+func (s *userServiceImpl) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, finish := startOperation(ctx, "DeleteUser", "delete_user", attribute.String("user.id", id))
+	defer finish(&err)
+	if err = lockStore(ctx, writeLock); err != nil {
+		return err
+	}
+	defer unlockStore(writeLock)
 	foundIndex := -1
 	for i := range userStore {
 		if userStore[i].ID == id {
@@ -244,9 +483,88 @@ func (s *userServiceImpl) DeleteUser(id string) error {
 		}
 	}
 	if foundIndex == -1 {
-		return ErrUserNotFound
+		zerolog.Ctx(ctx).Debug().Str("user_id", id).Msg("User to delete not found")
+
+		return userNotFound(id)
 	}
 	userStore = append(userStore[:foundIndex], userStore[foundIndex+1:]...)
+	metrics.StoreUsers.Set(float64(len(userStore)))
+	zerolog.Ctx(ctx).Info().Str("user_id", id).Msg("User deleted")
+
+	return nil
+}
+
+// Ping verifies that the in-memory store can be read before ctx is done.
+//
+// The store is always present in memory, so the only way for it to be unreachable
+// is a writer holding the lock for too long (or a deadlock).
+//
+// Returns:
+//   - nil if the read lock was acquired.
+//   - ErrStoreUnavailable, wrapping the context error, if ctx is done first.
+//
+// This function is safe for concurrent use.
+func (s *userServiceImpl) Ping(ctx context.Context) error {
+	if err := lockStore(ctx, readLock); err != nil {
+		return err
+	}
+	unlockStore(readLock)
 
 	return nil
 }
+
+// emailTaken reports whether a user other than the one identified by exceptID has
+// the email address (case-insensitive). The caller must hold storeLock.
+func emailTaken(email, exceptID string) bool {
+	for i := range userStore {
+		if userStore[i].ID != exceptID && strings.EqualFold(userStore[i].Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NormalizePhone returns number in E.164 format, reading numbers without a
+// country calling code as numbers of s.phoneRegion.
+//
+// Parameters:
+//   - number: The phone number as entered, e.g. "(201) 555-0123".
+//
+// Returns:
+//   - The number in E.164 format, e.g. "+12015550123".
+//   - An error matching ErrInvalidPhone if number is not a valid phone number.
+func (s *userServiceImpl) NormalizePhone(number string) (string, error) {
+	normalized, err := models.NormalizePhone(number, s.phoneRegion)
+	if err != nil {
+		return "", invalidPhone(err)
+	}
+
+	return normalized, nil
+}
+
+// fakePhone returns a random, valid US phone number in E.164 format for the seed data.
+func fakePhone() string {
+	for {
+		if phone, err := models.NormalizePhone(faker.Phonenumber(), "US"); err == nil {
+			return phone
+		}
+	}
+}
+
+// geocode returns the coordinates of address, or nil if there is no geocoder or
+// the address cannot be geocoded. Failures are logged but do not fail the write,
+// as the coordinates are only informative.
+func (s *userServiceImpl) geocode(ctx context.Context, address models.Address) *models.Coordinates {
+	if s.geocoder == nil || address.IsZero() {
+		return nil
+	}
+	coordinates, err := s.geocoder.Geocode(ctx, address)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("country", address.Country).Msg("Failed to geocode address")
+
+		return nil
+	}
+
+	return &coordinates
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-faker/faker/v4"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/models"
+)
+
+// newTestUser creates a user with the given email address through service.
+func newTestUser(t *testing.T, service UserService, email string) *models.User {
+	t.Helper()
+	user, err := service.CreateUser(context.Background(), models.User{
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		Email:     email,
+		Phone:     "+4722334455",
+		Active:    true,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	t.Cleanup(func() { _ = service.DeleteUser(context.Background(), user.ID) })
+
+	return user
+}
+
+func TestUpdateUserFieldsKeepsConcurrentChanges(t *testing.T) {
+	service := NewUserService(nil, "NO")
+	user := newTestUser(t, service, "concurrent@example.com")
+
+	calls := 0
+	updated, err := service.UpdateUserFields(context.Background(), user.ID, func(u *models.User) {
+		calls++
+		if calls == 1 {
+			// An administrator deactivates the account while the patch is being prepared
+			deactivated := *user
+			deactivated.Active = false
+			if _, err := service.UpdateUser(context.Background(), user.ID, deactivated); err != nil {
+				t.Errorf("UpdateUser() error = %v", err)
+			}
+		}
+		u.FirstName = "Augusta"
+	})
+	if err != nil {
+		t.Fatalf("UpdateUserFields() error = %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("update called %d times, want 2", calls)
+	}
+	if updated.FirstName != "Augusta" {
+		t.Errorf("FirstName = %q, want %q", updated.FirstName, "Augusta")
+	}
+	if updated.Active {
+		t.Error("Active = true, want the concurrent deactivation to be kept")
+	}
+	if updated.Revision != user.Revision+2 {
+		t.Errorf("Revision = %d, want %d", updated.Revision, user.Revision+2)
+	}
+}
+
+func TestUpdateUserFieldsIgnoresManagedFields(t *testing.T) {
+	service := NewUserService(nil, "NO")
+	user := newTestUser(t, service, "managed@example.com")
+
+	updated, err := service.UpdateUserFields(context.Background(), user.ID, func(u *models.User) {
+		u.ID = "other"
+		u.CreatedAt = u.CreatedAt.Add(-1)
+		u.Revision = 42
+		u.Phone = "22 33 44 56"
+	})
+	if err != nil {
+		t.Fatalf("UpdateUserFields() error = %v", err)
+	}
+
+	if updated.ID != user.ID || !updated.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("ID, CreatedAt = %q, %v, want %q, %v", updated.ID, updated.CreatedAt, user.ID, user.CreatedAt)
+	}
+	if updated.Phone != "+4722334456" {
+		t.Errorf("Phone = %q, want it normalised to %q", updated.Phone, "+4722334456")
+	}
+	if updated.Revision != user.Revision+1 {
+		t.Errorf("Revision = %d, want %d", updated.Revision, user.Revision+1)
+	}
+	if updated.UpdatedAt.Before(user.UpdatedAt) {
+		t.Errorf("UpdatedAt = %v, want not before %v", updated.UpdatedAt, user.UpdatedAt)
+	}
+}
+
+func TestUpdateUserFieldsErrors(t *testing.T) {
+	service := NewUserService(nil, "NO")
+	user := newTestUser(t, service, "errors@example.com")
+	newTestUser(t, service, "taken@example.com")
+
+	tests := []struct {
+		name    string
+		id      string
+		update  func(u *models.User)
+		wantErr error
+	}{
+		{
+			name:    "unknown user",
+			id:      "00000000-0000-0000-0000-000000000000",
+			update:  func(*models.User) {},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:    "invalid phone",
+			id:      user.ID,
+			update:  func(u *models.User) { u.Phone = "12" },
+			wantErr: ErrInvalidPhone,
+		},
+		{
+			name:    "email of another user",
+			id:      user.ID,
+			update:  func(u *models.User) { u.Email = "TAKEN@example.com" },
+			wantErr: ErrEmailTaken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.UpdateUserFields(context.Background(), tt.id, tt.update)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateUserFields() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	stored, err := service.GetUserByID(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("GetUserByID() error = %v", err)
+	}
+	if stored.Revision != user.Revision || stored.Email != user.Email || stored.Phone != user.Phone {
+		t.Errorf("stored user changed by failed updates: %+v", stored)
+	}
+}
+
+func TestSeedAddressMigratesLegacyAddresses(t *testing.T) {
+	// faker draws from a thousand real addresses; sample enough to see most of them
+	for range 5000 {
+		address := faker.GetRealAddress()
+		got := seedAddress(address)
+
+		if len(got.Lines) != 1 || got.Lines[0] != address.Address || got.City != address.City ||
+			got.Region != address.State || got.PostalCode != address.PostalCode || got.Country != "US" {
+			t.Fatalf("seedAddress(%+v) = %#v, want its parts in the structured fields", address, got)
+		}
+		if got.Coordinates == nil || got.Coordinates.Latitude != address.Coordinates.Latitude ||
+			got.Coordinates.Longitude != address.Coordinates.Longitude {
+			t.Fatalf("seedAddress(%+v): Coordinates = %+v, want the real ones", address, got.Coordinates)
+		}
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		region  string
+		number  string
+		want    string
+		wantErr bool
+	}{
+		{region: "NO", number: "22 33 44 55", want: "+4722334455"},
+		{region: "no", number: "22 33 44 55", want: "+4722334455"},
+		{region: "US", number: "(201) 555-0123", want: "+12015550123"},
+		{region: "US", number: "+47 22 33 44 55", want: "+4722334455"},
+		{region: "NO", number: "0044 20 7946 0018", want: "+442079460018"},
+		{region: "US", number: "22 33 44 55", wantErr: true},
+		{region: "NO", number: "12", wantErr: true},
+		{region: "NO", number: "not a number", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.region+" "+tt.number, func(t *testing.T) {
+			got, err := NewUserService(nil, tt.region).NormalizePhone(tt.number)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPhone) {
+					t.Errorf("NormalizePhone() error = %v, want ErrInvalidPhone", err)
+				}
+
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NormalizePhone() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Package tracing configures OpenTelemetry distributed tracing for the service:
+// the global tracer provider, the span exporter and W3C trace context propagation.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exporter names accepted by Setup.
+const (
+	// ExporterNone disables tracing. Spans are still created but never recorded.
+	ExporterNone = "none"
+	// ExporterOTLP sends spans to an OTLP/HTTP collector. The endpoint, headers and
+	// TLS settings are read from the standard OTEL_EXPORTER_OTLP_* environment variables.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans as JSON to standard output, for local testing.
+	ExporterStdout = "stdout"
+	// ExporterFile writes spans as JSON lines to a file, for local testing.
+	ExporterFile = "file"
+)
+
+// ErrUnknownExporter is returned by Setup for an unsupported exporter name.
+var ErrUnknownExporter = errors.New("unknown trace exporter")
+
+// Config holds the tracing options passed to Setup.
+type Config struct {
+	// Exporter selects where spans are sent: ExporterNone, ExporterOTLP, ExporterStdout or ExporterFile.
+	Exporter string
+	// FilePath is the file spans are appended to when Exporter is ExporterFile.
+	FilePath string
+	// ServiceName is reported as the service.name resource attribute.
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES take precedence when set.
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are sampled, between 0 and 1.
+	// Traces started upstream keep the sampling decision of their parent.
+	SampleRatio float64
+}
+
+// ShutdownFunc flushes buffered spans and releases the exporter.
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global OpenTelemetry tracer provider and the W3C trace context
+// and baggage propagators according to conf.
+//
+// The propagators are installed even when tracing is disabled, so incoming
+// `traceparent` headers are still forwarded to downstream calls.
+//
+// Returns:
+//   - A ShutdownFunc that must be called before the process exits to flush spans.
+//   - An error if the exporter cannot be created.
+func Setup(ctx context.Context, conf Config) (ShutdownFunc, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(conf.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+
+		return err
+	}, nil
+}
+
+// newExporter creates the span exporter selected by conf.Exporter, along with an
+// optional resource to close after the exporter has been shut down.
+// It returns a nil exporter when tracing is disabled.
+func newExporter(ctx context.Context, conf Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch conf.Exporter {
+	case ExporterNone, "":
+		return nil, nil, nil
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+		}
+
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, nil, fmt.Errorf("create stdout trace exporter: %w", err)
+		}
+
+		return exporter, nil, nil
+	case ExporterFile:
+		file, err := os.OpenFile(conf.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("open trace file %q: %w", conf.FilePath, err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			return nil, nil, errors.Join(fmt.Errorf("create file trace exporter: %w", err), file.Close())
+		}
+
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownExporter, conf.Exporter)
+	}
+}
@@ -0,0 +1,43 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name    string
+		conf    Config
+		wantErr error
+	}{
+		{name: "disabled", conf: Config{Exporter: ExporterNone}},
+		{name: "default", conf: Config{}},
+		{name: "file", conf: Config{Exporter: ExporterFile, FilePath: filepath.Join(t.TempDir(), "spans.jsonl"), SampleRatio: 1}},
+		{name: "unknown exporter", conf: Config{Exporter: "zipkin"}, wantErr: ErrUnknownExporter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdown, err := Setup(context.Background(), tt.conf)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Setup() error = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatalf("Setup() error = %v", err)
+			}
+			if err := shutdown(context.Background()); err != nil {
+				t.Errorf("shutdown() error = %v", err)
+			}
+		})
+	}
+
+	if _, err := Setup(context.Background(), Config{Exporter: ExporterFile, FilePath: t.TempDir()}); err == nil {
+		t.Error("Setup() with a directory as trace file succeeded, want an error")
+	}
+}
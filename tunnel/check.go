@@ -0,0 +1,125 @@
+// Package tunnel monitors the cloudflared connector running next to the service,
+// which is the only way traffic reaches it through the Cloudflare tunnel.
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/prometheus/common/expfmt"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+// haConnectionsMetric is the cloudflared gauge holding the number of active
+// connections between the connector and the Cloudflare edge.
+const haConnectionsMetric = "cloudflared_tunnel_ha_connections"
+
+// maxMetricsSize bounds how much of the metrics response is read. Larger responses
+// are rejected rather than parsed truncated.
+const maxMetricsSize = 4 << 20
+
+var (
+	// ErrTunnelDisconnected is reported when cloudflared has no connection to the edge.
+	ErrTunnelDisconnected = errors.New("tunnel has no active edge connections")
+	// ErrTunnelUnderReplicated is reported when cloudflared has fewer edge connections than expected.
+	ErrTunnelUnderReplicated = errors.New("tunnel has fewer edge connections than expected")
+	// ErrMetricMissing is reported when the scraped metrics do not contain the connection gauge.
+	ErrMetricMissing = errors.New("cloudflared metric " + haConnectionsMetric + " not found")
+	// ErrMetricsTooLarge is reported when the metrics response exceeds maxMetricsSize.
+	ErrMetricsTooLarge = errors.New("cloudflared metrics response too large")
+)
+
+// NewMetricsCheck returns a health.CheckFunc that scrapes the cloudflared Prometheus
+// metrics endpoint at metricsURL and inspects the number of active HA connections
+// to the Cloudflare edge.
+//
+// The tunnel is not required to serve requests arriving by other routes, so every
+// problem (scrape failure, no connections, fewer than minConnections) is reported
+// as health.Degraded rather than taking the service out of rotation.
+//
+// Every scrape also updates the tunnel gauges in the metrics package, so the
+// connector's state is visible in the service's own metrics.
+//
+// Parameters:
+//   - client: The HTTP client used for scraping. Its timeout is superseded by the check timeout.
+//   - metricsURL: The cloudflared metrics endpoint, e.g. "http://tunnel:60123/metrics".
+//   - minConnections: The number of edge connections expected from a healthy connector
+//     (cloudflared opens 4 by default).
+//
+// Returns:
+//   - A health.CheckFunc to register on a health.Registry.
+func NewMetricsCheck(client *http.Client, metricsURL string, minConnections int) health.CheckFunc {
+	return func(ctx context.Context) error {
+		connections, err := scrapeConnections(ctx, client, metricsURL)
+		if err != nil {
+			metrics.TunnelUp.Set(0)
+
+			return health.Degraded(err)
+		}
+
+		metrics.TunnelUp.Set(1)
+		metrics.TunnelHAConnections.Set(connections)
+
+		switch {
+		case connections < 1:
+			return health.Degraded(ErrTunnelDisconnected)
+		case connections < float64(minConnections):
+			return health.Degraded(fmt.Errorf("%w: %v of %d", ErrTunnelUnderReplicated, connections, minConnections))
+		default:
+			return nil
+		}
+	}
+}
+
+// scrapeConnections fetches the cloudflared metrics and returns the value of the
+// HA connections gauge.
+func scrapeConnections(ctx context.Context, client *http.Client, metricsURL string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("build cloudflared metrics request: %w", err)
+	}
+	req.Header.Set("Accept", string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("scrape cloudflared metrics: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // Read-only response body
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("scrape cloudflared metrics: unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxMetricsSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("read cloudflared metrics: %w", err)
+	}
+	if len(body) > maxMetricsSize {
+		return 0, fmt.Errorf("%w: more than %d bytes", ErrMetricsTooLarge, maxMetricsSize)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("parse cloudflared metrics: %w", err)
+	}
+
+	family, ok := families[haConnectionsMetric]
+	if !ok || len(family.GetMetric()) == 0 {
+		return 0, ErrMetricMissing
+	}
+
+	// Sum across label sets; accept untyped samples from exporters omitting # TYPE
+	var connections float64
+	for _, metric := range family.GetMetric() {
+		connections += metric.GetGauge().GetValue() + metric.GetUntyped().GetValue()
+	}
+
+	return connections, nil
+}
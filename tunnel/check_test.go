@@ -0,0 +1,146 @@
+package tunnel
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/thoughtgears/cloudflare-tunnels-poc/health"
+	"github.com/thoughtgears/cloudflare-tunnels-poc/metrics"
+)
+
+func TestMetricsCheck(t *testing.T) {
+	const gauge = "# HELP cloudflared_tunnel_ha_connections Number of active ha connections\n" +
+		"# TYPE cloudflared_tunnel_ha_connections gauge\n"
+
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantErr         error // nil: the check passes
+		wantUp          float64
+		wantConnections float64
+	}{
+		{
+			name:            "healthy",
+			status:          http.StatusOK,
+			body:            gauge + "cloudflared_tunnel_ha_connections 4\n",
+			wantUp:          1,
+			wantConnections: 4,
+		},
+		{
+			name:            "summed across label sets",
+			status:          http.StatusOK,
+			body:            gauge + "cloudflared_tunnel_ha_connections{conn=\"a\"} 2\ncloudflared_tunnel_ha_connections{conn=\"b\"} 2\n",
+			wantUp:          1,
+			wantConnections: 4,
+		},
+		{
+			name:            "untyped sample",
+			status:          http.StatusOK,
+			body:            "cloudflared_tunnel_ha_connections 4\n",
+			wantUp:          1,
+			wantConnections: 4,
+		},
+		{
+			name:    "missing gauge",
+			status:  http.StatusOK,
+			body:    "# TYPE go_goroutines gauge\ngo_goroutines 12\n",
+			wantErr: ErrMetricMissing,
+		},
+		{
+			name:    "no connections",
+			status:  http.StatusOK,
+			body:    gauge + "cloudflared_tunnel_ha_connections 0\n",
+			wantErr: ErrTunnelDisconnected,
+			wantUp:  1,
+		},
+		{
+			name:            "fewer than expected connections",
+			status:          http.StatusOK,
+			body:            gauge + "cloudflared_tunnel_ha_connections 2\n",
+			wantErr:         ErrTunnelUnderReplicated,
+			wantUp:          1,
+			wantConnections: 2,
+		},
+		{
+			name:    "non-200 response",
+			status:  http.StatusServiceUnavailable,
+			body:    gauge + "cloudflared_tunnel_ha_connections 4\n",
+			wantErr: errors.New("unexpected status 503 Service Unavailable"),
+		},
+		{
+			name:    "oversized body",
+			status:  http.StatusOK,
+			body:    strings.Repeat("# padding\n", maxMetricsSize/10) + gauge + "cloudflared_tunnel_ha_connections 4\n",
+			wantErr: ErrMetricsTooLarge,
+		},
+		{
+			name:    "malformed body",
+			status:  http.StatusOK,
+			body:    "cloudflared_tunnel_ha_connections four\n",
+			wantErr: errors.New("parse cloudflared metrics"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			// Leave a stale value behind to see the scrape overwrite it
+			metrics.TunnelHAConnections.Set(-1)
+			err := NewMetricsCheck(server.Client(), server.URL, 4)(context.Background())
+
+			switch {
+			case tt.wantErr == nil:
+				if err != nil {
+					t.Errorf("check error = %v, want nil", err)
+				}
+			case !isDegraded(err):
+				t.Errorf("check error = %v, want it reported as degraded", err)
+			case errors.Is(err, tt.wantErr):
+			case !strings.Contains(err.Error(), tt.wantErr.Error()):
+				t.Errorf("check error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := testutil.ToFloat64(metrics.TunnelUp); got != tt.wantUp {
+				t.Errorf("tunnel up = %v, want %v", got, tt.wantUp)
+			}
+			wantConnections := tt.wantConnections
+			if tt.wantUp == 0 {
+				wantConnections = -1 // left untouched by failed scrapes
+			}
+			if got := testutil.ToFloat64(metrics.TunnelHAConnections); got != wantConnections {
+				t.Errorf("tunnel ha connections = %v, want %v", got, wantConnections)
+			}
+		})
+	}
+}
+
+func TestMetricsCheckUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	if err := NewMetricsCheck(http.DefaultClient, url, 4)(context.Background()); !isDegraded(err) {
+		t.Errorf("check error = %v, want a degraded connection error", err)
+	}
+}
+
+// isDegraded reports whether err marks the check as degraded, as the registry sees it.
+func isDegraded(err error) bool {
+	registry := health.NewRegistry(health.NewReadiness(), 0)
+	registry.Register("tunnel", time.Second, func(context.Context) error { return err })
+
+	return err != nil && registry.Check().Status == health.StatusDegraded
+}